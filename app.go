@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	admin_controller "github.com/asishshaji/admin-api/controller"
@@ -24,45 +26,106 @@ type Controllers struct {
 	AdminController admin_controller.IAdminController
 }
 
-func NewApp(port string, controller Controllers) *App {
+func NewApp(port string, controller Controllers, isRevoked utils.RevocationChecker) *App {
 	e := echo.New()
+	e.IPExtractor = ipExtractor(os.Getenv("TRUSTED_PROXIES"))
 
 	e.Use(middleware.Logger())
 	e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(20)))
 	e.Use(middleware.Secure())
 
+	jwtMiddleware := middleware.JWTWithConfig(middleware.JWTConfig{
+		Claims:     &models.AdminJWTClaims{},
+		SigningKey: []byte(os.Getenv("JWT_SECRET")),
+	})
+	revocationMiddleware := utils.TokenRevocationMiddleware(isRevoked)
+
 	e.POST("/login", controller.AdminController.Login)
+	e.POST("/login/totp", controller.AdminController.LoginTOTP)
+	e.POST("/password/reset", controller.AdminController.ResetPassword)
+	e.POST("/refresh", controller.AdminController.Refresh)
+	e.POST("/logout", controller.AdminController.Logout, jwtMiddleware, revocationMiddleware, utils.AdminAuthenticationMiddleware)
 	e.GET("/data", controller.AdminController.GetData)
 
 	adminGroup := e.Group("/admin")
 
-	adminGroup.Use(middleware.JWTWithConfig(middleware.JWTConfig{
-		Claims:     &models.AdminJWTClaims{},
-		SigningKey: []byte(os.Getenv("JWT_SECRET")),
-	}))
-
+	adminGroup.Use(jwtMiddleware)
+	adminGroup.Use(revocationMiddleware)
 	adminGroup.Use(utils.AdminAuthenticationMiddleware)
 
-	adminGroup.POST("/task", controller.AdminController.CreateTask)
-	adminGroup.PUT("/task", controller.AdminController.UpdateTask)
-	adminGroup.GET("/task", controller.AdminController.GetTasks)
-	adminGroup.DELETE("/task", controller.AdminController.DeleteTask)
-
-	adminGroup.GET("/users", controller.AdminController.GetUsers)
-
-	adminGroup.GET("/submission", controller.AdminController.GetTaskSubmissions)
-	adminGroup.PUT("/submission", controller.AdminController.EditTaskSubmissionStatus)
-
-	adminGroup.GET("/user/submission/:id", controller.AdminController.GetTaskSubmissionForUser)
-
-	adminGroup.GET("/mentor", controller.AdminController.GetMentors)
-	adminGroup.POST("/mentor", controller.AdminController.CreateMentor)
-	adminGroup.PUT("/mentor", controller.AdminController.UpdateMentor)
-
-	adminGroup.POST("/domain", controller.AdminController.CreateDomain)
-	adminGroup.POST("/college", controller.AdminController.CreateCollege)
-	adminGroup.POST("/course", controller.AdminController.CreateCourse)
-	adminGroup.POST("/upload", controller.AdminController.UploadFile)
+	adminGroup.PUT("/me/password", controller.AdminController.ChangePassword)
+	adminGroup.POST("/me/totp", controller.AdminController.BeginTOTPEnrolment)
+	adminGroup.POST("/me/totp/verify", controller.AdminController.ConfirmTOTPEnrolment)
+	adminGroup.DELETE("/me/totp", controller.AdminController.DisableTOTP)
+
+	adminGroup.POST("/task", controller.AdminController.CreateTask, utils.RequirePermission(models.WRITE_TASKS))
+	adminGroup.PUT("/task", controller.AdminController.UpdateTask, utils.RequirePermission(models.WRITE_TASKS))
+	adminGroup.GET("/task", controller.AdminController.GetTasks, utils.RequirePermission(models.READ_TASKS))
+	adminGroup.DELETE("/task", controller.AdminController.DeleteTask, utils.RequirePermission(models.WRITE_TASKS))
+	adminGroup.POST("/task/:id/restore", controller.AdminController.RestoreTask, utils.RequirePermission(models.WRITE_TASKS))
+	adminGroup.GET("/task/:id/versions", controller.AdminController.GetTaskVersions, utils.RequirePermission(models.READ_TASKS))
+	adminGroup.GET("/task/:id/audience", controller.AdminController.GetTaskAudience, utils.RequirePermission(models.READ_TASKS))
+
+	adminGroup.GET("/users", controller.AdminController.GetUsers, utils.RequirePermission(models.READ_USERS))
+	adminGroup.GET("/users/export", controller.AdminController.ExportUsers, utils.RequirePermission(models.READ_USERS))
+	adminGroup.POST("/users/import", controller.AdminController.ImportStudents, utils.RequirePermission(models.WRITE_USERS))
+	adminGroup.GET("/users/:id", controller.AdminController.GetStudent, utils.RequirePermission(models.READ_USERS))
+	adminGroup.PUT("/users/:id", controller.AdminController.UpdateStudent, utils.RequirePermission(models.WRITE_USERS))
+	adminGroup.DELETE("/users/:id", controller.AdminController.DeactivateStudent, utils.RequirePermission(models.WRITE_USERS))
+	adminGroup.GET("/search", controller.AdminController.Search, utils.RequirePermission(models.READ_USERS))
+
+	adminGroup.GET("/submission", controller.AdminController.GetTaskSubmissions, utils.RequirePermission(models.READ_SUBMISSIONS))
+	adminGroup.GET("/submission/export", controller.AdminController.ExportTaskSubmissions, utils.RequirePermission(models.READ_SUBMISSIONS))
+	adminGroup.PUT("/submission", controller.AdminController.EditTaskSubmissionStatus, utils.RequirePermission(models.REVIEW_SUBMISSIONS))
+	adminGroup.PUT("/submission/bulk", controller.AdminController.BulkEditTaskSubmissionStatus, utils.RequirePermission(models.REVIEW_SUBMISSIONS))
+
+	adminGroup.GET("/user/submission/:id", controller.AdminController.GetTaskSubmissionForUser, utils.RequirePermission(models.READ_SUBMISSIONS))
+
+	adminGroup.GET("/mentor", controller.AdminController.GetMentors, utils.RequirePermission(models.READ_MENTORS))
+	adminGroup.POST("/mentor", controller.AdminController.CreateMentor, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.PUT("/mentor", controller.AdminController.UpdateMentor, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.GET("/mentor/:id", controller.AdminController.GetMentor, utils.RequirePermission(models.READ_MENTORS))
+	adminGroup.DELETE("/mentor/:id", controller.AdminController.DeleteMentor, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.POST("/mentor/:id/videos", controller.AdminController.AddMentorVideo, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.PUT("/mentor/:id/videos/order", controller.AdminController.ReorderMentorVideos, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.DELETE("/mentor/:id/videos/:video_id", controller.AdminController.RemoveMentorVideo, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.PUT("/mentor/:id/availability", controller.AdminController.SetMentorAvailability, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.GET("/videos", controller.AdminController.GetVideos, utils.RequirePermission(models.READ_MENTORS))
+	adminGroup.GET("/mentor/:id/students", controller.AdminController.GetMentorStudents, utils.RequirePermission(models.READ_MENTORS))
+	adminGroup.POST("/mentor/:id/students", controller.AdminController.AssignMentor, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.DELETE("/mentor/:id/students", controller.AdminController.UnassignMentor, utils.RequirePermission(models.WRITE_MENTORS))
+
+	adminGroup.GET("/sessions", controller.AdminController.GetSessions, utils.RequirePermission(models.READ_MENTORS))
+	adminGroup.POST("/sessions", controller.AdminController.CreateSession, utils.RequirePermission(models.WRITE_MENTORS))
+	adminGroup.POST("/sessions/:id/cancel", controller.AdminController.CancelSession, utils.RequirePermission(models.WRITE_MENTORS))
+
+	adminGroup.GET("/domain", controller.AdminController.GetDomains, utils.RequirePermission(models.READ_STATIC_DATA))
+	adminGroup.POST("/domain", controller.AdminController.CreateDomain, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.PUT("/domain/:name", controller.AdminController.RenameDomain, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.DELETE("/domain/:name", controller.AdminController.DeleteDomain, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.GET("/college", controller.AdminController.GetColleges, utils.RequirePermission(models.READ_STATIC_DATA))
+	adminGroup.POST("/college", controller.AdminController.CreateCollege, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.PUT("/college/:name", controller.AdminController.RenameCollege, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.DELETE("/college/:name", controller.AdminController.DeleteCollege, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.GET("/course", controller.AdminController.GetCourses, utils.RequirePermission(models.READ_STATIC_DATA))
+	adminGroup.POST("/course", controller.AdminController.CreateCourse, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.PUT("/course/:name", controller.AdminController.RenameCourse, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.DELETE("/course/:name", controller.AdminController.DeleteCourse, utils.RequirePermission(models.WRITE_STATIC_DATA))
+	adminGroup.POST("/upload", controller.AdminController.UploadFile, utils.RequirePermission(models.UPLOAD_FILES))
+
+	adminsGroup := adminGroup.Group("/admins", utils.RequirePermission(models.MANAGE_ADMINS))
+
+	adminsGroup.POST("", controller.AdminController.CreateAdmin)
+	adminsGroup.GET("", controller.AdminController.GetAdmins)
+	adminsGroup.PUT("/:id", controller.AdminController.SetAdminStatus)
+	adminsGroup.DELETE("/:id", controller.AdminController.DeleteAdmin)
+	adminsGroup.DELETE("/:id/totp", controller.AdminController.ResetAdminTOTP)
+	adminsGroup.POST("/:id/password-reset", controller.AdminController.CreatePasswordReset)
+
+	adminGroup.GET("/audit", controller.AdminController.GetAuditEntries, utils.RequirePermission(models.READ_AUDIT))
+
+	adminGroup.GET("/lockouts", controller.AdminController.GetLoginLockouts, utils.RequirePermission(models.MANAGE_ADMINS))
+	adminGroup.DELETE("/lockouts", controller.AdminController.ClearLoginLockout, utils.RequirePermission(models.MANAGE_ADMINS))
 
 	return &App{
 		app:  e,
@@ -70,6 +133,31 @@ func NewApp(port string, controller Controllers) *App {
 	}
 }
 
+// ipExtractor uses the connection address unless trustedProxies lists the comma separated
+// CIDR ranges of the proxies in front of the server, only then X-Forwarded-For is read and
+// only hops from those ranges are skipped
+func ipExtractor(trustedProxies string) echo.IPExtractor {
+	if strings.TrimSpace(trustedProxies) == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, cidr := range strings.Split(trustedProxies, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			log.Fatalf("Invalid trusted proxy range %q: %v", cidr, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 func (a *App) RunServer() {
 
 	go func() {
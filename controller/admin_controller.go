@@ -1,14 +1,23 @@
 package admin_controller
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/asishshaji/admin-api/models"
 	"github.com/asishshaji/admin-api/services/admin_service"
 	file_service "github.com/asishshaji/admin-api/services/file"
+	"github.com/asishshaji/admin-api/utils"
+	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -43,337 +52,1625 @@ func (aC AdminController) Login(c echo.Context) error {
 		return echo.ErrBadRequest
 	}
 
-	token, err := aC.adminService.Login(c.Request().Context(), username, password)
+	res, err := aC.adminService.Login(c.Request().Context(), username, password, c.RealIP())
 	if err != nil {
-		aC.l.Println(err)
-		return c.JSON(http.StatusForbidden, models.Response{
-			Message: err.Error(),
-		})
+		return aC.loginErrorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusOK, models.Response{
-		Message: token,
+		Message: res,
 	})
 }
 
-func (aC AdminController) GetUsers(c echo.Context) error {
-	students, err := aC.adminService.GetUsers(c.Request().Context())
+func (aC AdminController) LoginTOTP(c echo.Context) error {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	challengeToken, _ := json_map["challenge_token"].(string)
+	code, _ := json_map["code"].(string)
+	recoveryCode, _ := json_map["recovery_code"].(string)
 
+	if len(challengeToken) == 0 || (len(code) == 0 && len(recoveryCode) == 0) {
+		return echo.ErrBadRequest
+	}
+
+	res, err := aC.adminService.VerifyLoginTOTP(c.Request().Context(), challengeToken, code, recoveryCode, c.RealIP())
 	if err != nil {
-		return echo.ErrInternalServerError
+		return aC.loginErrorResponse(c, err)
 	}
 
-	return c.JSON(http.StatusOK, students)
+	return c.JSON(http.StatusOK, models.Response{
+		Message: res,
+	})
 }
 
-// Admin end
+// loginUnauthorizedErrors and loginForbiddenErrors are the login failures the caller is told about,
+// the response carries the text of the sentinel rather than of the wrapped error
+var (
+	loginUnauthorizedErrors = []error{models.ErrInvalidCredentials, models.ErrInvalidTOTPCode, models.ErrInvalidToken, models.ErrTokenRevoked}
+	loginForbiddenErrors    = []error{models.ErrAdminDisabled, models.ErrTOTPNotEnabled}
+)
 
-// Tasks start
+func (aC AdminController) loginErrorResponse(c echo.Context, err error) error {
+	var lockedErr models.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		c.Response().Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(lockedErr.RetryAfter.Seconds())), 10))
+		return c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Code:    "login_locked",
+			Message: lockedErr.Error(),
+		})
+	}
 
-func (aC AdminController) CreateTask(c echo.Context) error {
+	aC.l.Println(err)
 
-	adminId := c.Get("admin_id").(primitive.ObjectID)
+	for _, loginErr := range loginUnauthorizedErrors {
+		if errors.Is(err, loginErr) {
+			return c.JSON(http.StatusUnauthorized, models.Response{
+				Message: loginErr.Error(),
+			})
+		}
+	}
+	for _, loginErr := range loginForbiddenErrors {
+		if errors.Is(err, loginErr) {
+			return c.JSON(http.StatusForbidden, models.Response{
+				Message: loginErr.Error(),
+			})
+		}
+	}
 
-	task := models.TaskDTO{}
+	// anything else is a failing backend, its details stay in the log
+	return c.JSON(http.StatusServiceUnavailable, models.Response{
+		Message: "unable to log in, try again later",
+	})
+}
 
-	if err := json.NewDecoder(c.Request().Body).Decode(&task); err != nil {
+func (aC AdminController) Refresh(c echo.Context) error {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
 		aC.l.Println(err)
 		return echo.ErrBadRequest
-
 	}
 
-	err := aC.adminService.AddTask(c.Request().Context(), task, adminId)
+	refreshToken, ok := json_map["refresh_token"].(string)
+	if !ok || len(refreshToken) == 0 {
+		return echo.ErrBadRequest
+	}
 
+	tokens, err := aC.adminService.Refresh(c.Request().Context(), refreshToken)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.Response{
-			Message: "Error creating task",
+		aC.l.Println(err)
+		return c.JSON(http.StatusUnauthorized, models.Response{
+			Message: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusCreated, models.Response{
-		Message: "Created task",
+	return c.JSON(http.StatusOK, models.Response{
+		Message: tokens,
 	})
 }
 
-func (aC AdminController) UpdateTask(c echo.Context) error {
-
-	task := models.TaskDTO{}
+func (aC AdminController) Logout(c echo.Context) error {
+	claims := c.Get("user").(*jwt.Token).Claims.(*models.AdminJWTClaims)
 
-	if err := json.NewDecoder(c.Request().Body).Decode(&task); err != nil {
+	// the refresh token is optional, without it only the access token is revoked
+	json_map := make(map[string]interface{})
+	if err := json.NewDecoder(c.Request().Body).Decode(&json_map); err != nil && err != io.EOF {
 		aC.l.Println(err)
 		return echo.ErrBadRequest
-
 	}
+	refreshToken, _ := json_map["refresh_token"].(string)
 
-	err := task.Validate()
-	if err != nil {
-		aC.l.Println(err)
-		return echo.ErrInternalServerError
+	err := aC.adminService.Logout(c.Request().Context(), claims, refreshToken)
+	if err == models.ErrInvalidToken {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
 	}
-
-	err = aC.adminService.UpdateTask(c.Request().Context(), task)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.Response{
-			Message: "Error updating task",
-		})
+		return echo.ErrInternalServerError
 	}
 
 	return c.JSON(http.StatusOK, models.Response{
-		Message: "Updated task",
+		Message: "logged out",
 	})
 }
 
-func (aC AdminController) DeleteTask(c echo.Context) error {
-	id := c.FormValue("task_id")
-	taskId, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		aC.l.Println("Error parsing task id")
-		return echo.ErrInternalServerError
+func (aC AdminController) CreateAdmin(c echo.Context) error {
+	admin := models.AdminDTO{}
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&admin); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := admin.Validate(); err != nil {
+		aC.l.Println(err)
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
 	}
 
-	err = aC.adminService.DeleteTask(c.Request().Context(), taskId)
+	err := aC.adminService.CreateAdmin(c.Request().Context(), admin)
+	if err == models.ErrAdminExists {
+		return c.JSON(http.StatusConflict, models.Response{
+			Message: err.Error(),
+		})
+	}
 	if err != nil {
-		return echo.ErrInternalServerError
+		return c.JSON(http.StatusInternalServerError, models.Response{
+			Message: "Error creating admin",
+		})
 	}
 
-	return c.JSON(http.StatusAccepted, models.Response{
-		Message: "deleted task",
+	return c.JSON(http.StatusCreated, models.Response{
+		Message: "created admin",
 	})
 }
 
-func (aC AdminController) GetTasks(c echo.Context) error {
-	tasks, err := aC.adminService.GetTasks(c.Request().Context())
+func (aC AdminController) GetAdmins(c echo.Context) error {
+	admins, err := aC.adminService.GetAdmins(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.Response{
-			Message: "Error getting tasks",
+			Message: "Error getting admins",
 		})
 	}
 
-	return c.JSON(http.StatusOK, tasks)
+	return c.JSON(http.StatusOK, admins)
 }
 
-// Tasks end
+func (aC AdminController) SetAdminStatus(c echo.Context) error {
+	actorId := c.Get("admin_id").(primitive.ObjectID)
+
+	adminId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing admin id")
+		return echo.ErrBadRequest
+	}
 
-func (aC AdminController) CreateDomain(c echo.Context) error {
 	json_map := make(map[string]interface{})
-	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	err = json.NewDecoder(c.Request().Body).Decode(&json_map)
 	if err != nil {
 		aC.l.Println(err)
-		return echo.ErrInternalServerError
+		return echo.ErrBadRequest
 	}
 
-	if json_map["domain"] == nil {
+	disabled, ok := json_map["disabled"].(bool)
+	if !ok {
 		return echo.ErrBadRequest
 	}
 
-	domainName := fmt.Sprintf("%v", json_map["domain"])
+	err = aC.adminService.SetAdminDisabled(c.Request().Context(), actorId, adminId, disabled)
+	if err != nil {
+		return aC.adminErrorResponse(c, err)
+	}
 
-	err = aC.adminService.CreateDomain(c.Request().Context(), domainName)
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "updated admin",
+	})
+}
+
+func (aC AdminController) DeleteAdmin(c echo.Context) error {
+	actorId := c.Get("admin_id").(primitive.ObjectID)
+
+	adminId, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		return echo.ErrInternalServerError
+		aC.l.Println("Error parsing admin id")
+		return echo.ErrBadRequest
 	}
 
-	return c.JSON(http.StatusCreated, models.Response{
-		Message: "created",
+	err = aC.adminService.DeleteAdmin(c.Request().Context(), actorId, adminId)
+	if err != nil {
+		return aC.adminErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "deleted admin",
 	})
 }
 
-func (aC AdminController) GetDomains(c echo.Context) error {
-	return nil
+func (aC AdminController) adminErrorResponse(c echo.Context, err error) error {
+	switch err {
+	case models.ErrNoAdminWithId:
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrCannotModifySelf:
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return echo.ErrInternalServerError
 }
 
-func (aC AdminController) CreateCollege(c echo.Context) error {
-	json_map := make(map[string]interface{})
-	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+func (aC AdminController) GetLoginLockouts(c echo.Context) error {
+	lockouts, err := aC.adminService.GetLoginLockouts(c.Request().Context())
 	if err != nil {
-		aC.l.Println(err)
 		return echo.ErrInternalServerError
 	}
-	if json_map["college"] == nil {
+
+	return c.JSON(http.StatusOK, lockouts)
+}
+
+func (aC AdminController) ClearLoginLockout(c echo.Context) error {
+	scope := models.LockoutScope(c.QueryParam("scope"))
+	key := c.QueryParam("key")
+
+	if scope.String() == "" || len(key) == 0 {
 		return echo.ErrBadRequest
 	}
-	college := fmt.Sprintf("%v", json_map["college"])
 
-	err = aC.adminService.CreateCollege(c.Request().Context(), college)
+	err := aC.adminService.ClearLoginLockout(c.Request().Context(), scope, key)
 	if err != nil {
 		return echo.ErrInternalServerError
 	}
 
-	return c.JSON(http.StatusCreated, models.Response{
-		Message: "created",
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "cleared lockout",
 	})
 }
 
-func (aC AdminController) CreateCourse(c echo.Context) error {
+func (aC AdminController) ChangePassword(c echo.Context) error {
+	adminId := c.Get("admin_id").(primitive.ObjectID)
+
 	json_map := make(map[string]interface{})
 	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
 	if err != nil {
 		aC.l.Println(err)
-		return echo.ErrInternalServerError
+		return echo.ErrBadRequest
 	}
 
-	if json_map["course"] == nil {
+	currentPassword, _ := json_map["current_password"].(string)
+	newPassword, _ := json_map["new_password"].(string)
+
+	if len(currentPassword) == 0 || len(newPassword) == 0 {
 		return echo.ErrBadRequest
 	}
 
-	course := fmt.Sprintf("%v", json_map["course"])
-
-	err = aC.adminService.CreateCourse(c.Request().Context(), course)
+	err = aC.adminService.ChangePassword(c.Request().Context(), adminId, currentPassword, newPassword)
 	if err != nil {
-		return echo.ErrInternalServerError
+		return aC.passwordErrorResponse(c, err)
 	}
 
-	return c.JSON(http.StatusCreated, models.Response{
-		Message: "created",
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "changed password",
 	})
 }
 
-// Tasks submissions start
+func (aC AdminController) CreatePasswordReset(c echo.Context) error {
+	actorId := c.Get("admin_id").(primitive.ObjectID)
 
-func (aC AdminController) GetTaskSubmissions(c echo.Context) error {
-	res, err := aC.adminService.GetTaskSubmissions(c.Request().Context())
+	adminId, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		aC.l.Println(err)
-		return echo.ErrInternalServerError
+		aC.l.Println("Error parsing admin id")
+		return echo.ErrBadRequest
 	}
-	return c.JSON(http.StatusOK, res)
-}
 
-func (aC AdminController) EditTaskSubmissionStatus(c echo.Context) error {
-	// get task id, check if task submission exists
-	// get task status, update task submission
-	json_map := make(map[string]interface{})
-	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	reset, err := aC.adminService.CreatePasswordReset(c.Request().Context(), actorId, adminId)
 	if err != nil {
-		aC.l.Println(err)
-		return echo.ErrInternalServerError
+		return aC.adminErrorResponse(c, err)
 	}
-	statusString := fmt.Sprintf("%v", json_map["status"])
 
-	taskId := fmt.Sprintf("%v", json_map["task_id"])
-	uid := fmt.Sprintf("%v", json_map["u_id"])
-	u_id, _ := primitive.ObjectIDFromHex(uid)
-
-	if statusString == "" {
-		aC.l.Println("Error parsing status")
-		return echo.ErrInternalServerError
-	}
+	return c.JSON(http.StatusCreated, reset)
+}
 
-	taskIdObj, err := primitive.ObjectIDFromHex(taskId)
+func (aC AdminController) ResetPassword(c echo.Context) error {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
 	if err != nil {
 		aC.l.Println(err)
 		return echo.ErrBadRequest
 	}
 
-	status := models.Status(statusString).String()
-	if status == "" {
+	token, _ := json_map["token"].(string)
+	newPassword, _ := json_map["new_password"].(string)
+
+	if len(token) == 0 || len(newPassword) == 0 {
 		return echo.ErrBadRequest
 	}
 
-	err = aC.adminService.EditTaskSubmission(c.Request().Context(), u_id, taskIdObj, models.Status(statusString))
+	err = aC.adminService.ResetPassword(c.Request().Context(), token, newPassword)
 	if err != nil {
-		return echo.ErrInternalServerError
+		return aC.passwordErrorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusAccepted, models.Response{
-		Message: "edited task submission",
+		Message: "reset password",
 	})
 }
 
-func (aC AdminController) GetTaskSubmissionForUser(c echo.Context) error {
-	userId := c.Param("id")
-	userIdObj, err := primitive.ObjectIDFromHex(userId)
-	if err != nil {
-		aC.l.Println("Error parsing user id")
-		return echo.ErrInternalServerError
-	}
-	tasks, err := aC.adminService.GetTaskSubmissionsForUser(c.Request().Context(), userIdObj)
-	if err != nil {
-		return c.JSON(http.StatusNotFound, models.Response{
+func (aC AdminController) passwordErrorResponse(c echo.Context, err error) error {
+	switch err {
+	case models.ErrWeakPassword:
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrInvalidCredentials, models.ErrInvalidResetToken:
+		return c.JSON(http.StatusForbidden, models.Response{
 			Message: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, tasks)
+	return echo.ErrInternalServerError
 }
 
-// Tasks submission ends
+func (aC AdminController) BeginTOTPEnrolment(c echo.Context) error {
+	adminId := c.Get("admin_id").(primitive.ObjectID)
 
-// Mentor starts
+	enrolment, err := aC.adminService.BeginTOTPEnrolment(c.Request().Context(), adminId)
+	if err != nil {
+		return aC.totpErrorResponse(c, err)
+	}
 
-func (aC AdminController) CreateMentor(c echo.Context) error {
-	mentor := new(models.MentorDTO)
+	return c.JSON(http.StatusOK, enrolment)
+}
 
-	if err := json.NewDecoder(c.Request().Body).Decode(mentor); err != nil {
-		aC.l.Println("Error parsing mentor body")
-		return echo.ErrInternalServerError
-	}
+func (aC AdminController) ConfirmTOTPEnrolment(c echo.Context) error {
+	adminId := c.Get("admin_id").(primitive.ObjectID)
 
-	if err := mentor.Validate(); err != nil {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
 		aC.l.Println(err)
-		return echo.ErrInternalServerError
+		return echo.ErrBadRequest
 	}
 
-	err := aC.adminService.CreateMentor(c.Request().Context(), *mentor)
+	code, _ := json_map["code"].(string)
+	if len(code) == 0 {
+		return echo.ErrBadRequest
+	}
 
+	recoveryCodes, err := aC.adminService.ConfirmTOTPEnrolment(c.Request().Context(), adminId, code)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.Response{
-			Message: err.Error(),
-		})
+		return aC.totpErrorResponse(c, err)
 	}
 
-	return c.JSON(http.StatusCreated, models.Response{
-		Message: "created mentor",
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": recoveryCodes,
 	})
 }
 
-func (aC AdminController) UpdateMentor(c echo.Context) error {
-
-	mentor := models.MentorDTO{}
+func (aC AdminController) DisableTOTP(c echo.Context) error {
+	adminId := c.Get("admin_id").(primitive.ObjectID)
 
-	if err := json.NewDecoder(c.Request().Body).Decode(&mentor); err != nil {
-		aC.l.Println("Error parsing mentor")
-		return echo.ErrInternalServerError
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
 	}
 
-	if err := mentor.Validate(); err != nil {
-		aC.l.Println(err)
-		return echo.ErrInternalServerError
+	password, _ := json_map["password"].(string)
+	if len(password) == 0 {
+		return echo.ErrBadRequest
 	}
 
-	err := aC.adminService.UpdateMentor(c.Request().Context(), mentor)
+	err = aC.adminService.DisableTOTP(c.Request().Context(), adminId, password)
 	if err != nil {
-		return echo.ErrInternalServerError
+		return aC.totpErrorResponse(c, err)
 	}
+
 	return c.JSON(http.StatusAccepted, models.Response{
-		Message: "updated mentor",
+		Message: "disabled two-factor authentication",
 	})
 }
 
-func (aC AdminController) GetMentors(c echo.Context) error {
-	mentors, err := aC.adminService.GetMentors(c.Request().Context())
+func (aC AdminController) ResetAdminTOTP(c echo.Context) error {
+	actorId := c.Get("admin_id").(primitive.ObjectID)
+
+	adminId, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.Response{
-			Message: err.Error(),
-		})
+		aC.l.Println("Error parsing admin id")
+		return echo.ErrBadRequest
 	}
-	return c.JSON(http.StatusOK, mentors)
-}
-
-// Mentor ends
-
-func (aC AdminController) GetData(c echo.Context) error {
 
-	domains, err := aC.adminService.GetData(c.Request().Context())
+	err = aC.adminService.ResetAdminTOTP(c.Request().Context(), actorId, adminId)
 	if err != nil {
-		aC.l.Println(err)
-		return echo.ErrInternalServerError
+		return aC.adminErrorResponse(c, err)
 	}
 
-	return c.JSON(http.StatusOK, domains)
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "reset two-factor authentication",
+	})
 }
 
-func (aC AdminController) UploadFile(c echo.Context) error {
+func (aC AdminController) totpErrorResponse(c echo.Context, err error) error {
+	switch err {
+	case models.ErrTOTPAlreadyEnabled, models.ErrTOTPNotEnabled, models.ErrTOTPEnrolmentNotStarted:
+		return c.JSON(http.StatusConflict, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrInvalidTOTPCode, models.ErrInvalidCredentials:
+		return c.JSON(http.StatusForbidden, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return echo.ErrInternalServerError
+}
+
+func (aC AdminController) GetUsers(c echo.Context) error {
+	filter := models.StudentFilter{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := filter.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	students, err := aC.adminService.GetUsers(c.Request().Context(), filter)
+
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, students)
+}
+
+func (aC AdminController) ExportUsers(c echo.Context) error {
+	filter := models.StudentFilter{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := filter.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return aC.export(c, "students", func(w utils.TableWriter) error {
+		return aC.adminService.ExportUsers(c.Request().Context(), filter, w)
+	})
+}
+
+// export streams a csv (default) or xlsx file chosen by the format query param. Once the
+// first row is out the status can not change, so later errors are only logged.
+func (aC AdminController) export(c echo.Context, name string, write func(w utils.TableWriter) error) error {
+	format := models.FileFormat(strings.ToLower(c.QueryParam("format")))
+	if format == "" {
+		format = models.CSV
+	}
+	if format != models.CSV && format != models.XLSX {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: "format must be csv or xlsx",
+		})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.ContentType())
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+"."+format.String()))
+	res.WriteHeader(http.StatusOK)
+
+	w, err := utils.NewTableWriter(res, format)
+	if err != nil {
+		aC.l.Println(err)
+		return nil
+	}
+
+	if err = write(w); err != nil {
+		aC.l.Println(err)
+	}
+
+	if err = w.Close(); err != nil {
+		aC.l.Println(err)
+	}
+
+	return nil
+}
+
+func (aC AdminController) GetStudent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing user id")
+		return echo.ErrBadRequest
+	}
+
+	student, err := aC.adminService.GetStudent(c.Request().Context(), id)
+	if err != nil {
+		return aC.studentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, student)
+}
+
+func (aC AdminController) UpdateStudent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing user id")
+		return echo.ErrBadRequest
+	}
+
+	student := models.StudentDTO{}
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&student); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := student.ValidateUpdate(); err != nil {
+		aC.l.Println(err)
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	err = aC.adminService.UpdateStudent(c.Request().Context(), id, student)
+	if err != nil {
+		return aC.studentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "updated student",
+	})
+}
+
+func (aC AdminController) DeactivateStudent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing user id")
+		return echo.ErrBadRequest
+	}
+
+	err = aC.adminService.DeactivateStudent(c.Request().Context(), id)
+	if err != nil {
+		return aC.studentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "deactivated student",
+	})
+}
+
+// ImportStudents takes a csv or xlsx file in the "file" form field. The format is taken from
+// the file name unless the format query param is set, dry_run only validates the rows.
+func (aC AdminController) ImportStudents(c echo.Context) error {
+	file, header, err := c.Request().FormFile("file")
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+	defer file.Close()
+
+	format := models.FileFormat(strings.ToLower(c.QueryParam("format")))
+	if format == "" {
+		format = utils.FileFormatFromName(header.Filename)
+	}
+	if format != models.CSV && format != models.XLSX {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: "file must be csv or xlsx",
+		})
+	}
+
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	rows, err := utils.ReadTable(file, format)
+	if err != nil {
+		aC.l.Println(err)
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	report, err := aC.adminService.ImportStudents(c.Request().Context(), rows, dryRun)
+	if err == models.ErrEmptyImport {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+
+	return c.JSON(status, report)
+}
+
+func (aC AdminController) studentErrorResponse(c echo.Context, err error) error {
+	switch err {
+	case models.ErrNoStudentWithIdExists:
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrStudentEmailTaken:
+		return c.JSON(http.StatusConflict, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return echo.ErrInternalServerError
+}
+
+// Admin end
+
+// Tasks start
+
+func (aC AdminController) CreateTask(c echo.Context) error {
+
+	adminId := c.Get("admin_id").(primitive.ObjectID)
+
+	task := models.TaskDTO{}
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&task); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+
+	}
+
+	if err := task.Validate(); err != nil {
+		aC.l.Println(err)
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	err := aC.adminService.AddTask(c.Request().Context(), task, adminId)
+
+	if errors.Is(err, models.ErrInvalidAssignment) {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.Response{
+			Message: "Error creating task",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.Response{
+		Message: "Created task",
+	})
+}
+
+func (aC AdminController) UpdateTask(c echo.Context) error {
+
+	task := models.TaskDTO{}
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&task); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+
+	}
+
+	err := task.Validate()
+	if err != nil {
+		aC.l.Println(err)
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	err = aC.adminService.UpdateTask(c.Request().Context(), task)
+	if errors.Is(err, models.ErrInvalidAssignment) || err == models.ErrInvalidTaskId {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	if err == models.ErrNoValidRecordFound || err == models.ErrTaskModified || err == models.ErrTaskArchived {
+		return aC.taskErrorResponse(c, err)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.Response{
+			Message: "Error updating task",
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.Response{
+		Message: "Updated task",
+	})
+}
+
+func (aC AdminController) DeleteTask(c echo.Context) error {
+	id := c.FormValue("task_id")
+	taskId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		aC.l.Println("Error parsing task id")
+		return echo.ErrInternalServerError
+	}
+
+	cascade := models.CASCADE_BLOCK
+	if v := c.FormValue("cascade"); v != "" {
+		cascade = models.TaskCascade(v)
+	}
+	if cascade.String() == "" {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: models.ErrInvalidCascade.Error(),
+		})
+	}
+
+	report, err := aC.adminService.DeleteTask(c.Request().Context(), taskId, cascade)
+	if err != nil {
+		return aC.taskErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (aC AdminController) RestoreTask(c echo.Context) error {
+	taskId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing task id")
+		return echo.ErrBadRequest
+	}
+
+	report, err := aC.adminService.RestoreTask(c.Request().Context(), taskId)
+	if err != nil {
+		return aC.taskErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (aC AdminController) taskErrorResponse(c echo.Context, err error) error {
+	var submissionsErr models.TaskHasSubmissionsError
+	if errors.As(err, &submissionsErr) {
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "task_has_submissions",
+			Message: submissionsErr.Error(),
+			Details: submissionsErr,
+		})
+	}
+
+	switch err {
+	case models.ErrNoValidRecordFound:
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrTaskArchived, models.ErrTaskNotArchived, models.ErrTaskModified:
+		return c.JSON(http.StatusConflict, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	aC.l.Println(err)
+	return echo.ErrInternalServerError
+}
+
+func (aC AdminController) GetTasks(c echo.Context) error {
+	includeArchived, _ := strconv.ParseBool(c.QueryParam("include_archived"))
+
+	tasks, err := aC.adminService.GetTasks(c.Request().Context(), includeArchived)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.Response{
+			Message: "Error getting tasks",
+		})
+	}
+
+	return c.JSON(http.StatusOK, tasks)
+}
+
+func (aC AdminController) GetTaskVersions(c echo.Context) error {
+	taskId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing task id")
+		return echo.ErrBadRequest
+	}
+
+	versions, err := aC.adminService.GetTaskVersions(c.Request().Context(), taskId)
+	if err == models.ErrNoValidRecordFound {
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+func (aC AdminController) GetTaskAudience(c echo.Context) error {
+	taskId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing task id")
+		return echo.ErrBadRequest
+	}
+
+	audience, err := aC.adminService.GetTaskAudience(c.Request().Context(), taskId)
+	if err == models.ErrNoValidRecordFound {
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, audience)
+}
+
+// Tasks end
+
+func (aC AdminController) CreateDomain(c echo.Context) error {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+
+	if json_map["domain"] == nil {
+		return echo.ErrBadRequest
+	}
+
+	domainName := fmt.Sprintf("%v", json_map["domain"])
+
+	err = aC.adminService.CreateDomain(c.Request().Context(), domainName)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusCreated, models.Response{
+		Message: "created",
+	})
+}
+
+func (aC AdminController) GetDomains(c echo.Context) error {
+	return aC.getStaticData(c, models.STATIC_DOMAINS)
+}
+
+func (aC AdminController) GetColleges(c echo.Context) error {
+	return aC.getStaticData(c, models.STATIC_COLLEGES)
+}
+
+func (aC AdminController) GetCourses(c echo.Context) error {
+	return aC.getStaticData(c, models.STATIC_COURSES)
+}
+
+func (aC AdminController) RenameDomain(c echo.Context) error {
+	return aC.renameStaticData(c, models.STATIC_DOMAINS)
+}
+
+func (aC AdminController) RenameCollege(c echo.Context) error {
+	return aC.renameStaticData(c, models.STATIC_COLLEGES)
+}
+
+func (aC AdminController) RenameCourse(c echo.Context) error {
+	return aC.renameStaticData(c, models.STATIC_COURSES)
+}
+
+func (aC AdminController) DeleteDomain(c echo.Context) error {
+	return aC.deleteStaticData(c, models.STATIC_DOMAINS)
+}
+
+func (aC AdminController) DeleteCollege(c echo.Context) error {
+	return aC.deleteStaticData(c, models.STATIC_COLLEGES)
+}
+
+func (aC AdminController) DeleteCourse(c echo.Context) error {
+	return aC.deleteStaticData(c, models.STATIC_COURSES)
+}
+
+func (aC AdminController) getStaticData(c echo.Context, kind models.StaticDataKind) error {
+	data, err := aC.adminService.GetStaticData(c.Request().Context(), kind)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, data)
+}
+
+// staticNameParam reads the url escaped name of a domain, college or course
+func staticNameParam(c echo.Context) string {
+	name, err := url.PathUnescape(c.Param("name"))
+	if err != nil {
+		return c.Param("name")
+	}
+	return name
+}
+
+func (aC AdminController) renameStaticData(c echo.Context, kind models.StaticDataKind) error {
+	body := struct {
+		Name string `json:"name"`
+	}{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return echo.ErrBadRequest
+	}
+
+	report, err := aC.adminService.RenameStaticData(c.Request().Context(), kind, staticNameParam(c), name)
+	if err != nil {
+		return aC.staticDataErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (aC AdminController) deleteStaticData(c echo.Context, kind models.StaticDataKind) error {
+	if err := aC.adminService.DeleteStaticData(c.Request().Context(), kind, staticNameParam(c)); err != nil {
+		return aC.staticDataErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.Response{
+		Message: "deleted",
+	})
+}
+
+func (aC AdminController) staticDataErrorResponse(c echo.Context, err error) error {
+	var inUseErr models.StaticDataInUseError
+	if errors.As(err, &inUseErr) {
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "static_data_in_use",
+			Message: inUseErr.Error(),
+			Details: inUseErr,
+		})
+	}
+
+	switch err {
+	case models.ErrNoValidRecordFound:
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrStaticDataExists:
+		return c.JSON(http.StatusConflict, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	aC.l.Println(err)
+	return echo.ErrInternalServerError
+}
+
+func (aC AdminController) CreateCollege(c echo.Context) error {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+	if json_map["college"] == nil {
+		return echo.ErrBadRequest
+	}
+	college := fmt.Sprintf("%v", json_map["college"])
+
+	err = aC.adminService.CreateCollege(c.Request().Context(), college)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusCreated, models.Response{
+		Message: "created",
+	})
+}
+
+func (aC AdminController) CreateCourse(c echo.Context) error {
+	json_map := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&json_map)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+
+	if json_map["course"] == nil {
+		return echo.ErrBadRequest
+	}
+
+	course := fmt.Sprintf("%v", json_map["course"])
+
+	err = aC.adminService.CreateCourse(c.Request().Context(), course)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusCreated, models.Response{
+		Message: "created",
+	})
+}
+
+// Tasks submissions start
+
+func (aC AdminController) GetTaskSubmissions(c echo.Context) error {
+	filter, err := aC.bindSubmissionFilter(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := aC.adminService.GetTaskSubmissions(c.Request().Context(), filter)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (aC AdminController) ExportTaskSubmissions(c echo.Context) error {
+	filter, err := aC.bindSubmissionFilter(c)
+	if err != nil {
+		return err
+	}
+
+	return aC.export(c, "submissions", func(w utils.TableWriter) error {
+		return aC.adminService.ExportTaskSubmissions(c.Request().Context(), filter, w)
+	})
+}
+
+// bindSubmissionFilter returns the filter from the query params, or the response to send
+// when they are invalid
+func (aC AdminController) bindSubmissionFilter(c echo.Context) (models.SubmissionFilter, error) {
+	filter := models.SubmissionFilter{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		aC.l.Println(err)
+		return filter, echo.ErrBadRequest
+	}
+
+	if err := filter.Validate(); err != nil {
+		return filter, echo.NewHTTPError(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return filter, nil
+}
+
+func (aC AdminController) EditTaskSubmissionStatus(c echo.Context) error {
+	// task_id is the id of the submission, the student is taken from the submission itself
+	adminId := c.Get("admin_id").(primitive.ObjectID)
+
+	review := models.SubmissionReviewDTO{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&review); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := review.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	submissionId, err := primitive.ObjectIDFromHex(review.SubmissionId)
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	err = aC.adminService.EditTaskSubmission(c.Request().Context(), adminId, submissionId, review.Status, review.Feedback)
+	if err != nil {
+		return aC.submissionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "edited task submission",
+	})
+}
+
+// BulkEditTaskSubmissionStatus applies one status and feedback to many submissions, the
+// response reports the outcome of every submission
+func (aC AdminController) BulkEditTaskSubmissionStatus(c echo.Context) error {
+	adminId := c.Get("admin_id").(primitive.ObjectID)
+
+	review := models.BulkSubmissionReviewDTO{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&review); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := review.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	submissionIds := []primitive.ObjectID{}
+	for _, id := range review.SubmissionIds {
+		submissionId, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.Response{
+				Message: fmt.Sprintf("invalid submission id %q", id),
+			})
+		}
+		if !seen[submissionId] {
+			seen[submissionId] = true
+			submissionIds = append(submissionIds, submissionId)
+		}
+	}
+
+	report := aC.adminService.BulkEditTaskSubmissions(c.Request().Context(), adminId, submissionIds, review.Status, review.Feedback)
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (aC AdminController) submissionErrorResponse(c echo.Context, err error) error {
+	var transitionErr models.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "invalid_transition",
+			Message: transitionErr.Error(),
+			Details: transitionErr,
+		})
+	}
+
+	switch err {
+	case models.ErrNoValidRecordFound:
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrSubmissionModified:
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "submission_modified",
+			Message: err.Error(),
+		})
+	case models.ErrSubmissionArchived:
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "submission_archived",
+			Message: err.Error(),
+		})
+	}
+
+	aC.l.Println(err)
+	return echo.ErrInternalServerError
+}
+
+func (aC AdminController) GetTaskSubmissionForUser(c echo.Context) error {
+	userId := c.Param("id")
+	userIdObj, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		aC.l.Println("Error parsing user id")
+		return echo.ErrInternalServerError
+	}
+	tasks, err := aC.adminService.GetTaskSubmissionsForUser(c.Request().Context(), userIdObj)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, tasks)
+}
+
+// Tasks submission ends
+
+// Mentor starts
+
+func (aC AdminController) CreateMentor(c echo.Context) error {
+	mentor := new(models.MentorDTO)
+
+	if err := json.NewDecoder(c.Request().Body).Decode(mentor); err != nil {
+		aC.l.Println("Error parsing mentor body")
+		return echo.ErrInternalServerError
+	}
+
+	if err := mentor.Validate(); err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+
+	err := aC.adminService.CreateMentor(c.Request().Context(), *mentor)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.Response{
+		Message: "created mentor",
+	})
+}
+
+func (aC AdminController) UpdateMentor(c echo.Context) error {
+
+	mentor := models.MentorDTO{}
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&mentor); err != nil {
+		aC.l.Println("Error parsing mentor")
+		return echo.ErrInternalServerError
+	}
+
+	if err := mentor.Validate(); err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+
+	err := aC.adminService.UpdateMentor(c.Request().Context(), mentor)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusAccepted, models.Response{
+		Message: "updated mentor",
+	})
+}
+
+func (aC AdminController) AssignMentor(c echo.Context) error {
+	return aC.changeMentorAssignment(c, aC.adminService.AssignMentor)
+}
+
+func (aC AdminController) UnassignMentor(c echo.Context) error {
+	return aC.changeMentorAssignment(c, aC.adminService.UnassignMentor)
+}
+
+type mentorAssignmentFunc func(ctx context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (models.MentorAssignmentReport, error)
+
+func (aC AdminController) changeMentorAssignment(c echo.Context, change mentorAssignmentFunc) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	selection := models.MentorAssignmentDTO{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&selection); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := selection.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	report, err := change(c.Request().Context(), mentorId, selection)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (aC AdminController) GetMentorStudents(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	students, err := aC.adminService.GetMentorStudents(c.Request().Context(), mentorId)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, students)
+}
+
+func (aC AdminController) GetMentor(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	mentor, err := aC.adminService.GetMentor(c.Request().Context(), mentorId)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, mentor)
+}
+
+func (aC AdminController) DeleteMentor(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	detach := models.DETACH_BLOCK
+	if v := c.QueryParam("students"); v != "" {
+		detach = models.MentorDetach(v)
+	}
+	if detach.String() == "" {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: models.ErrInvalidDetach.Error(),
+		})
+	}
+
+	report, err := aC.adminService.DeleteMentor(c.Request().Context(), mentorId, detach)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// AddMentorVideo takes the video as json with a video url, or as a multipart form with the
+// video in "file" which is uploaded and gets a thumbnail
+func (aC AdminController) AddMentorVideo(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	video := models.VideoDTO{}
+	if err := c.Bind(&video); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := video.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	if file, _, err := c.Request().FormFile("file"); err == nil {
+		defer file.Close()
+
+		videoUrl, thumbUrl, err := aC.fileService.UploadVideo(c.Request().Context(), file)
+		if err != nil {
+			return echo.ErrInternalServerError
+		}
+		aC.adminService.RecordUpload(c.Request().Context(), videoUrl)
+
+		video.VideoUrl = videoUrl
+		if video.ThumbUrl == "" {
+			video.ThumbUrl = thumbUrl
+		}
+	}
+
+	created, err := aC.adminService.AddMentorVideo(c.Request().Context(), mentorId, video)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+func (aC AdminController) RemoveMentorVideo(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	videoId, err := primitive.ObjectIDFromHex(c.Param("video_id"))
+	if err != nil {
+		aC.l.Println("Error parsing video id")
+		return echo.ErrBadRequest
+	}
+
+	if err := aC.adminService.RemoveMentorVideo(c.Request().Context(), mentorId, videoId); err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.Response{
+		Message: "removed video",
+	})
+}
+
+func (aC AdminController) ReorderMentorVideos(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	order := models.VideoOrderDTO{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&order); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	videos, err := aC.adminService.ReorderMentorVideos(c.Request().Context(), mentorId, order.VideoIds)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, videos)
+}
+
+func (aC AdminController) GetVideos(c echo.Context) error {
+	videos, err := aC.adminService.GetVideos(c.Request().Context(), c.QueryParam("domain"))
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, videos)
+}
+
+func (aC AdminController) SetMentorAvailability(c echo.Context) error {
+	mentorId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing mentor id")
+		return echo.ErrBadRequest
+	}
+
+	availability := models.AvailabilityDTO{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&availability); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := availability.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	if err := aC.adminService.SetMentorAvailability(c.Request().Context(), mentorId, availability.Slots); err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, availability)
+}
+
+func (aC AdminController) CreateSession(c echo.Context) error {
+	adminId := c.Get("admin_id").(primitive.ObjectID)
+
+	session := models.SessionDTO{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&session); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if err := session.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	created, err := aC.adminService.CreateSession(c.Request().Context(), adminId, session)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+func (aC AdminController) CancelSession(c echo.Context) error {
+	sessionId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		aC.l.Println("Error parsing session id")
+		return echo.ErrBadRequest
+	}
+
+	cancel := models.SessionCancelDTO{}
+	if c.Request().ContentLength != 0 {
+		if err := json.NewDecoder(c.Request().Body).Decode(&cancel); err != nil {
+			aC.l.Println(err)
+			return echo.ErrBadRequest
+		}
+	}
+
+	if err := cancel.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	session, err := aC.adminService.CancelSession(c.Request().Context(), sessionId, cancel.Reason)
+	if err != nil {
+		return aC.mentorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+func (aC AdminController) GetSessions(c echo.Context) error {
+	filter := models.SessionFilter{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if filter.MentorId != "" && !primitive.IsValidObjectID(filter.MentorId) {
+		return echo.ErrBadRequest
+	}
+	if filter.StudentId != "" && !primitive.IsValidObjectID(filter.StudentId) {
+		return echo.ErrBadRequest
+	}
+	if filter.Status != "" && filter.Status.String() == "" {
+		return echo.ErrBadRequest
+	}
+
+	sessions, err := aC.adminService.GetSessions(c.Request().Context(), filter)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+func (aC AdminController) mentorErrorResponse(c echo.Context, err error) error {
+	var studentsErr models.MentorHasStudentsError
+	if errors.As(err, &studentsErr) {
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "mentor_has_students",
+			Message: studentsErr.Error(),
+			Details: studentsErr,
+		})
+	}
+
+	var conflictErr models.SessionConflictError
+	if errors.As(err, &conflictErr) {
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "session_conflict",
+			Message: conflictErr.Error(),
+			Details: conflictErr,
+		})
+	}
+
+	switch err {
+	case models.ErrMentorArchived, models.ErrMentorModified, models.ErrSessionNotScheduled, models.ErrStudentDeactivated:
+		return c.JSON(http.StatusConflict, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrVideoNotFound, models.ErrNoStudentWithIdExists:
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	case models.ErrVideoRequired, models.ErrInvalidVideoDomain, models.ErrInvalidVideoOrder, models.ErrOutsideAvailability:
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	var capacityErr models.MentorCapacityError
+	if errors.As(err, &capacityErr) {
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    "mentor_capacity_exceeded",
+			Message: capacityErr.Error(),
+			Details: capacityErr,
+		})
+	}
+
+	if errors.Is(err, models.ErrInvalidCohort) {
+		return c.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	if err == models.ErrNoValidRecordFound {
+		return c.JSON(http.StatusNotFound, models.Response{
+			Message: err.Error(),
+		})
+	}
+
+	aC.l.Println(err)
+	return echo.ErrInternalServerError
+}
+
+func (aC AdminController) GetMentors(c echo.Context) error {
+	includeArchived, _ := strconv.ParseBool(c.QueryParam("include_archived"))
+
+	mentors, err := aC.adminService.GetMentors(c.Request().Context(), includeArchived)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.Response{
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, mentors)
+}
+
+// Mentor ends
+
+func (aC AdminController) GetData(c echo.Context) error {
+
+	domains, err := aC.adminService.GetData(c.Request().Context())
+	if err != nil {
+		aC.l.Println(err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, domains)
+}
+
+func (aC AdminController) UploadFile(c echo.Context) error {
 	image, _, _ := c.Request().FormFile("file")
 
 	url, err := aC.fileService.UploadFile(c.Request().Context(), image)
@@ -382,8 +1679,46 @@ func (aC AdminController) UploadFile(c echo.Context) error {
 		return echo.ErrInternalServerError
 	}
 
+	aC.adminService.RecordUpload(c.Request().Context(), url)
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"url": url,
 	})
 
 }
+
+func (aC AdminController) GetAuditEntries(c echo.Context) error {
+	filter := models.AuditFilter{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+		aC.l.Println(err)
+		return echo.ErrBadRequest
+	}
+
+	if filter.ActorId != "" && !primitive.IsValidObjectID(filter.ActorId) {
+		return echo.ErrBadRequest
+	}
+
+	entries, err := aC.adminService.GetAuditEntries(c.Request().Context(), filter)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func (aC AdminController) Search(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if len(query) == 0 {
+		return echo.ErrBadRequest
+	}
+
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+
+	results, err := aC.adminService.Search(c.Request().Context(), query, limit)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
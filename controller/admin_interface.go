@@ -6,30 +6,85 @@ type IAdminController interface {
 
 	// users
 	Login(c echo.Context) error
+	LoginTOTP(c echo.Context) error
+	Refresh(c echo.Context) error
+	Logout(c echo.Context) error
 	GetUsers(c echo.Context) error
+	GetStudent(c echo.Context) error
+	UpdateStudent(c echo.Context) error
+	DeactivateStudent(c echo.Context) error
+	ImportStudents(c echo.Context) error
+	ExportUsers(c echo.Context) error
+
+	// admins
+	CreateAdmin(c echo.Context) error
+	GetAdmins(c echo.Context) error
+	SetAdminStatus(c echo.Context) error
+	DeleteAdmin(c echo.Context) error
+	GetLoginLockouts(c echo.Context) error
+	ClearLoginLockout(c echo.Context) error
+	ResetAdminTOTP(c echo.Context) error
+	CreatePasswordReset(c echo.Context) error
+	ResetPassword(c echo.Context) error
+
+	// current admin
+	ChangePassword(c echo.Context) error
+	BeginTOTPEnrolment(c echo.Context) error
+	ConfirmTOTPEnrolment(c echo.Context) error
+	DisableTOTP(c echo.Context) error
 
 	CreateDomain(c echo.Context) error // create and update
 	GetDomains(c echo.Context) error
 	CreateCollege(c echo.Context) error
 	CreateCourse(c echo.Context) error
+	GetColleges(c echo.Context) error
+	GetCourses(c echo.Context) error
+	RenameDomain(c echo.Context) error
+	RenameCollege(c echo.Context) error
+	RenameCourse(c echo.Context) error
+	DeleteDomain(c echo.Context) error
+	DeleteCollege(c echo.Context) error
+	DeleteCourse(c echo.Context) error
 
 	// Tasks
 	CreateTask(c echo.Context) error
 	UpdateTask(c echo.Context) error
 	GetTasks(c echo.Context) error
+	GetTaskAudience(c echo.Context) error
+	GetTaskVersions(c echo.Context) error
 	DeleteTask(c echo.Context) error
+	RestoreTask(c echo.Context) error
 
 	// submissions
 	GetTaskSubmissions(c echo.Context) error
+	ExportTaskSubmissions(c echo.Context) error
 	GetTaskSubmissionForUser(c echo.Context) error
 	EditTaskSubmissionStatus(c echo.Context) error
+	BulkEditTaskSubmissionStatus(c echo.Context) error
 
 	// mentors
 	CreateMentor(c echo.Context) error
 	UpdateMentor(c echo.Context) error
 	GetMentors(c echo.Context) error
-	// DeleteMentor(c echo.Context) error
+	AssignMentor(c echo.Context) error
+	UnassignMentor(c echo.Context) error
+	GetMentorStudents(c echo.Context) error
+	GetMentor(c echo.Context) error
+	DeleteMentor(c echo.Context) error
+	AddMentorVideo(c echo.Context) error
+	RemoveMentorVideo(c echo.Context) error
+	ReorderMentorVideos(c echo.Context) error
+	GetVideos(c echo.Context) error
+	SetMentorAvailability(c echo.Context) error
+
+	// mentor sessions
+	CreateSession(c echo.Context) error
+	CancelSession(c echo.Context) error
+	GetSessions(c echo.Context) error
 
 	GetData(c echo.Context) error
 	UploadFile(c echo.Context) error
+
+	GetAuditEntries(c echo.Context) error
+	Search(c echo.Context) error
 }
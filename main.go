@@ -10,6 +10,7 @@ import (
 	"github.com/asishshaji/admin-api/services/admin_service"
 	file_service "github.com/asishshaji/admin-api/services/file"
 	"github.com/asishshaji/admin-api/services/notification_service"
+	"github.com/asishshaji/admin-api/services/reminder_service"
 	"github.com/asishshaji/admin-api/utils"
 	"github.com/go-redis/redis/v8"
 )
@@ -37,7 +38,50 @@ func main() {
 	fileService := file_service.NewFileService(logger)
 	onesignalService := notification_service.NewNotificationService(logger)
 
+	utils.CreateIndex(db, "admin", "username", true)
+	utils.CreateIndex(db, "admin_password_resets", "token_hash", true)
+	utils.CreateIndex(db, "audit_log", "created_at", false)
+	utils.CreateIndex(db, "audit_log", "entity_id", false)
+	utils.CreateIndex(db, "students", "createdat", false)
+	utils.CreateIndex(db, "students", "college", false)
+	utils.CreateIndex(db, "students", "domains", false)
+	utils.CreateIndex(db, "tasks", "dueat", false)
+	utils.CreateIndex(db, "task_versions", "task_id", false)
+	utils.CreateIndex(db, "mentor_sessions", "mentor_id", false)
+	utils.CreateIndex(db, "mentor_sessions", "student_id", false)
+	utils.CreateIndex(db, "mentor_sessions", "start_at", false)
+
+	if err := utils.CreateTextIndex(db, "students", map[string]int32{
+		"firstname":      5,
+		"middlename":     2,
+		"lastname":       5,
+		"email":          8,
+		"phonenumber":    10,
+		"phonenumberalt": 5,
+	}); err != nil {
+		logger.Println(err)
+	}
+	if err := utils.CreateTextIndex(db, "tasks", map[string]int32{
+		"title":  10,
+		"detail": 2,
+	}); err != nil {
+		logger.Println(err)
+	}
+	if err := utils.CreateTextIndex(db, "mentor", map[string]int32{
+		"name":         10,
+		"organization": 5,
+	}); err != nil {
+		logger.Println(err)
+	}
+
 	adminRepo := admin_repository.NewAdminRepository(logger, db)
+	if err := adminRepo.BackfillMentorVideos(context.Background()); err != nil {
+		logger.Println("Error backfilling mentor videos", err)
+	}
+	if err := adminRepo.BackfillMentorAssigned(context.Background()); err != nil {
+		logger.Println("Error backfilling mentor assigned counts", err)
+	}
+
 	adminService := admin_service.NewAdminService(logger, adminRepo, redisClient, onesignalService)
 	adminController := admin_controller.NewAdminController(logger, adminService, fileService)
 
@@ -51,10 +95,13 @@ func main() {
 		log.Fatalln("Error creating admin")
 	}
 
+	reminderService := reminder_service.NewReminderService(logger, adminRepo, onesignalService)
+	go reminderService.Run(context.Background())
+
 	controller := Controllers{
 		AdminController: adminController,
 	}
 
-	app := NewApp(env.ServerPort, controller)
+	app := NewApp(env.ServerPort, controller, adminService.IsTokenRevoked)
 	app.RunServer()
 }
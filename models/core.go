@@ -1,25 +1,76 @@
 package models
 
 import (
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AdminJWTClaims struct {
-	AdminId primitive.ObjectID
-	IsAdmin bool
+	AdminId     primitive.ObjectID
+	IsAdmin     bool
+	Role        Role
+	Permissions []Permission
+	TokenType   TokenType
 	jwt.StandardClaims
 }
 
+func (claims *AdminJWTClaims) HasPermission(permission Permission) bool {
+	for _, p := range claims.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 type Response struct {
 	Message interface{} `json:"message"`
 }
 
 type Admin struct {
-	ID       primitive.ObjectID `bson:"_id"`
-	Username string             `json:"username"`
-	Password string             `json:"-"`
+	ID        primitive.ObjectID `bson:"_id"`
+	Username  string             `json:"username"`
+	Password  string             `json:"-"`
+	Role      Role               `json:"role"`
+	Disabled  bool               `json:"disabled"`
+	CreatedAt primitive.DateTime `json:"created_at" bson:"created_at,omitempty"`
+	UpdatedAt primitive.DateTime `json:"updated_at" bson:"updated_at,omitempty"`
+
+	TOTPEnabled       bool     `json:"totp_enabled" bson:"totp_enabled"`
+	TOTPSecret        string   `json:"-" bson:"totp_secret,omitempty"`
+	TOTPPendingSecret string   `json:"-" bson:"totp_pending_secret,omitempty"`
+	TOTPLastStep      int64    `json:"-" bson:"totp_last_step,omitempty"`
+	RecoveryCodes     []string `json:"-" bson:"recovery_codes,omitempty"` // bcrypt hashes
+
+	// tokens issued before this are no longer accepted, it moves on password changes and resets,
+	// two-factor resets and when the admin is disabled or deleted
+	TokensValidAfter primitive.DateTime `json:"-" bson:"tokens_valid_after,omitempty"`
+}
+
+func (admin *Admin) ToResponse() AdminResponse {
+	return AdminResponse{
+		ID:          admin.ID,
+		Username:    admin.Username,
+		Role:        admin.Role,
+		Disabled:    admin.Disabled,
+		TOTPEnabled: admin.TOTPEnabled,
+		CreatedAt:   admin.CreatedAt,
+		UpdatedAt:   admin.UpdatedAt,
+	}
+}
+
+type PasswordReset struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AdminId   primitive.ObjectID `bson:"admin_id"`
+	TokenHash string             `bson:"token_hash"`
+	ExpiresAt primitive.DateTime `bson:"expires_at"`
+	UsedAt    primitive.DateTime `bson:"used_at,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"created_by"`
+	CreatedAt primitive.DateTime `bson:"created_at"`
 }
 
 type Student struct {
@@ -48,6 +99,37 @@ type Student struct {
 	DateOfJoining    string               `json:"date_of_joining"`
 	CourseEndingDate string               `json:"course_ending_date"`
 	Mentors          []primitive.ObjectID `json:"-"`
+	Status           StudentStatus        `json:"status" bson:",omitempty"` // empty for students created before deactivation existed
+	DeactivatedAt    primitive.DateTime   `json:"-" bson:",omitempty"`
+}
+
+func (stu Student) ToResponse() StudentResponse {
+	return StudentResponse{
+		ID:               stu.ID,
+		Email:            stu.Email,
+		FirstName:        stu.FirstName,
+		Domains:          stu.Domains,
+		LastName:         stu.LastName,
+		MiddleName:       stu.MiddleName,
+		CreatedAt:        stu.CreatedAt,
+		UpdatedAt:        stu.UpdatedAt,
+		DOB:              stu.DOB,
+		Gender:           Gender(stu.Gender).String(),
+		PhoneNumber:      stu.PhoneNumber,
+		PhoneNumberAlt:   stu.PhoneNumberAlt,
+		College:          stu.College,
+		Course:           stu.Course,
+		Specialization:   stu.Specialization,
+		HasArrears:       stu.HasArrears,
+		Place:            stu.Place,
+		Semester:         stu.Semester,
+		District:         stu.District,
+		State:            stu.State,
+		Country:          stu.Country,
+		DateOfJoining:    stu.DateOfJoining,
+		CourseEndingDate: stu.CourseEndingDate,
+		Status:           stu.Status.String(),
+	}
 }
 
 type Students []Student
@@ -56,31 +138,7 @@ func (students Students) ToStudentResponse() []StudentResponse {
 	studentReponse := []StudentResponse{}
 
 	for _, stu := range students {
-		studentReponse = append(studentReponse, StudentResponse{
-			ID:               stu.ID,
-			Email:            stu.Email,
-			FirstName:        stu.FirstName,
-			Domains:          stu.Domains,
-			LastName:         stu.LastName,
-			MiddleName:       stu.MiddleName,
-			CreatedAt:        stu.CreatedAt,
-			UpdatedAt:        stu.UpdatedAt,
-			DOB:              stu.DOB,
-			Gender:           Gender(stu.Gender).String(),
-			PhoneNumber:      stu.PhoneNumber,
-			PhoneNumberAlt:   stu.PhoneNumberAlt,
-			College:          stu.College,
-			Course:           stu.Course,
-			Specialization:   stu.Specialization,
-			HasArrears:       stu.HasArrears,
-			Place:            stu.Place,
-			Semester:         stu.Semester,
-			District:         stu.District,
-			State:            stu.State,
-			Country:          stu.Country,
-			DateOfJoining:    stu.DateOfJoining,
-			CourseEndingDate: stu.CourseEndingDate,
-		})
+		studentReponse = append(studentReponse, stu.ToResponse())
 	}
 
 	return studentReponse
@@ -94,9 +152,71 @@ type Mentor struct {
 	Organization string             `json:"organization" validate:"required"`
 	Domain       string             `json:"domain" validate:"required"`
 	Image        string             `json:"image"`
-	Videos       []Videos           `bson:"videos"`
-	CreatedAt    primitive.DateTime `bson:",omitempty"`
-	UpdatedAt    primitive.DateTime `bson:",omitempty"`
+	// managed through the video endpoints, omitted so mentor updates leave it alone
+	Videos    []Videos           `bson:"videos,omitempty"`
+	CreatedAt primitive.DateTime `bson:",omitempty"`
+	UpdatedAt primitive.DateTime `bson:",omitempty"`
+	// most students the mentor can be assigned, 0 is unlimited
+	Capacity   int                `json:"capacity" validate:"min=0"`
+	ArchivedAt primitive.DateTime `json:"archived_at,omitempty" bson:",omitempty"`
+	// active students holding a seat, changed only by $inc
+	Assigned int64 `json:"assigned" bson:",omitempty"`
+	// weekly office hours, set through the availability endpoint
+	Availability []AvailabilitySlot `json:"availability" bson:",omitempty"`
+}
+
+// AvailabilitySlot is a weekly recurring window, Start and End are "15:04" wall clock times in
+// TimeZone
+type AvailabilitySlot struct {
+	Weekday  time.Weekday `json:"weekday" validate:"min=0,max=6"`
+	Start    string       `json:"start" validate:"required"`
+	End      string       `json:"end" validate:"required"`
+	TimeZone string       `json:"time_zone" validate:"required"`
+}
+
+// Contains reports whether start to end falls on one occurrence of the slot
+func (s AvailabilitySlot) Contains(start, end time.Time) bool {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return false
+	}
+
+	localStart, localEnd := start.In(loc), end.In(loc)
+	if localStart.Weekday() != s.Weekday {
+		return false
+	}
+
+	from, err := time.Parse(clockLayout, s.Start)
+	if err != nil {
+		return false
+	}
+	to, err := time.Parse(clockLayout, s.End)
+	if err != nil {
+		return false
+	}
+
+	y, m, d := localStart.Date()
+	opens := time.Date(y, m, d, from.Hour(), from.Minute(), 0, 0, loc)
+	closes := time.Date(y, m, d, to.Hour(), to.Minute(), 0, 0, loc)
+
+	return !localStart.Before(opens) && !localEnd.After(closes)
+}
+
+// MentorSession is a booking of a student with a mentor, stored in mentor_sessions
+type MentorSession struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	MentorId  primitive.ObjectID `json:"mentor_id" bson:"mentor_id"`
+	StudentId primitive.ObjectID `json:"student_id" bson:"student_id"`
+	Topic     string             `json:"topic" bson:"topic"`
+	StartAt   primitive.DateTime `json:"start_at" bson:"start_at"`
+	EndAt     primitive.DateTime `json:"end_at" bson:"end_at"`
+	// time zone of the availability slot the session was booked in
+	TimeZone     string             `json:"time_zone" bson:"time_zone"`
+	Status       SessionStatus      `json:"status" bson:"status"`
+	CreatedBy    primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt    primitive.DateTime `json:"created_at" bson:"created_at"`
+	CancelledAt  primitive.DateTime `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+	CancelReason string             `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
 }
 
 func (mentor *Mentor) Validate() error {
@@ -116,6 +236,9 @@ func (dto *Mentor) ToResponse() *MentorResponse {
 		CreatedAt:    dto.CreatedAt,
 		Image:        dto.Image,
 		Videos:       dto.Videos,
+		Capacity:     dto.Capacity,
+		ArchivedAt:   dto.ArchivedAt,
+		Availability: dto.Availability,
 	}
 }
 
@@ -128,9 +251,41 @@ type Task struct {
 	CreatedAt primitive.DateTime `json:"created_at" bson:",omitempty"`
 	UpdatedAt primitive.DateTime `json:"updated_at" bson:",omitempty"`
 	CreatorID primitive.ObjectID `json:"creator_id"`
+	OpenAt    primitive.DateTime `json:"open_at,omitempty" bson:",omitempty"`
+	DueAt     primitive.DateTime `json:"due_at,omitempty" bson:",omitempty"`
+	// the due date reminders were last sent for, moving DueAt makes the task due for reminders again
+	ReminderDueAt primitive.DateTime `json:"-" bson:",omitempty"`
+	Assignment    TaskAssignment     `json:"assignment"`
+	Version       int                `json:"version"`
+	ArchivedAt    primitive.DateTime `json:"archived_at,omitempty" bson:",omitempty"`
+}
+
+// TaskVersion is a snapshot of a task as it was saved, a new one is stored on every edit
+type TaskVersion struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	TaskId    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	Version   int                `json:"version" bson:"version"`
+	Task      Task               `json:"task" bson:"task"`
+	EditedBy  primitive.ObjectID `json:"edited_by" bson:"edited_by"`
+	CreatedAt primitive.DateTime `json:"created_at" bson:"created_at"`
+}
+
+// TaskAssignment narrows who a task is for. Without one a task is for every student in its
+// domain and semester. Colleges and courses narrow that cohort and are only applied when
+// set, students are added individually whatever their domain or semester. A task with only
+// students assigned is for those students alone.
+type TaskAssignment struct {
+	Colleges   []string             `json:"colleges"`
+	Courses    []string             `json:"courses"`
+	StudentIds []primitive.ObjectID `json:"student_ids"`
+}
+
+func (a TaskAssignment) IsEmpty() bool {
+	return len(a.Colleges) == 0 && len(a.Courses) == 0 && len(a.StudentIds) == 0
 }
 
 type TaskSubmission struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
 	UserId    primitive.ObjectID `json:"userid"`
 	TaskId    primitive.ObjectID `json:"taskid"`
 	Comment   string             `json:"comment"`
@@ -138,15 +293,44 @@ type TaskSubmission struct {
 	Status    Status             `json:"status"`
 	CreatedAt primitive.DateTime `bson:",omitempty"`
 	UpdatedAt primitive.DateTime `bson:",omitempty"`
+	Reviews   []SubmissionReview `json:"reviews" bson:",omitempty"`
+	// the task version the submission was made against, when the student app does not set it
+	// the admin listings derive it from the task versions
+	TaskVersion int `json:"task_version" bson:",omitempty"`
+	// set when the submission was archived together with its task
+	ArchivedAt primitive.DateTime `json:"archived_at,omitempty" bson:",omitempty"`
 }
 
-type StaticModel struct {
-	Name      string
-	CreatedOn primitive.DateTime `bson:"created_at"`
+// SubmissionReview is a single review of a submission, reviews are only ever appended
+type SubmissionReview struct {
+	ReviewerId primitive.ObjectID `json:"reviewer_id"`
+	OldStatus  Status             `json:"old_status"`
+	NewStatus  Status             `json:"new_status"`
+	Feedback   string             `json:"feedback"`
+	CreatedAt  primitive.DateTime `json:"created_at"`
 }
 
-type TT struct {
-	Mentors []primitive.ObjectID
+// AuditEntry records a single admin mutation, entries are never updated or deleted
+type AuditEntry struct {
+	ID         primitive.ObjectID     `json:"_id" bson:"_id"`
+	ActorId    primitive.ObjectID     `json:"actor_id" bson:"actor_id"`
+	Action     string                 `json:"action" bson:"action"`
+	Collection string                 `json:"collection" bson:"collection"`
+	EntityId   string                 `json:"entity_id" bson:"entity_id"`
+	Before     bson.M                 `json:"before,omitempty" bson:"before,omitempty"`
+	After      bson.M                 `json:"after,omitempty" bson:"after,omitempty"`
+	Diff       map[string]AuditChange `json:"diff,omitempty" bson:"diff,omitempty"`
+	CreatedAt  primitive.DateTime     `json:"created_at" bson:"created_at"`
+}
+
+type AuditChange struct {
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+type StaticModel struct {
+	Name      string             `json:"name"`
+	CreatedOn primitive.DateTime `json:"created_at" bson:"created_at"`
 }
 
 type Token struct {
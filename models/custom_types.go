@@ -24,6 +24,8 @@ const (
 	COMPLETED Status = "completed" // verified by admin
 	INACTIVE  Status = "inactive"  // not started
 	REJECTED  Status = "rejected"
+	// the student is asked to resubmit, unlike rejected it is not final
+	NEEDS_REVISION Status = "needs_revision"
 )
 
 func (s Status) String() string {
@@ -36,6 +38,264 @@ func (s Status) String() string {
 		return "inactive"
 	case REJECTED:
 		return "rejected"
+	case NEEDS_REVISION:
+		return "needs_revision"
+	}
+	return ""
+}
+
+type Role string
+
+const (
+	SUPERADMIN     Role = "superadmin"
+	REVIEWER       Role = "reviewer"
+	CONTENT_EDITOR Role = "content_editor"
+)
+
+func (r Role) String() string {
+	switch r {
+	case SUPERADMIN:
+		return "superadmin"
+	case REVIEWER:
+		return "reviewer"
+	case CONTENT_EDITOR:
+		return "content_editor"
+	}
+	return ""
+}
+
+type Permission string
+
+const (
+	MANAGE_ADMINS      Permission = "admins:manage"
+	READ_AUDIT         Permission = "audit:read"
+	READ_USERS         Permission = "users:read"
+	WRITE_USERS        Permission = "users:write"
+	READ_TASKS         Permission = "tasks:read"
+	WRITE_TASKS        Permission = "tasks:write"
+	READ_SUBMISSIONS   Permission = "submissions:read"
+	REVIEW_SUBMISSIONS Permission = "submissions:review"
+	READ_MENTORS       Permission = "mentors:read"
+	WRITE_MENTORS      Permission = "mentors:write"
+	READ_STATIC_DATA   Permission = "static_data:read"
+	WRITE_STATIC_DATA  Permission = "static_data:write"
+	UPLOAD_FILES       Permission = "files:upload"
+)
+
+var rolePermissions = map[Role][]Permission{
+	SUPERADMIN: {
+		MANAGE_ADMINS,
+		READ_AUDIT,
+		READ_USERS,
+		WRITE_USERS,
+		READ_TASKS,
+		WRITE_TASKS,
+		READ_SUBMISSIONS,
+		REVIEW_SUBMISSIONS,
+		READ_MENTORS,
+		WRITE_MENTORS,
+		READ_STATIC_DATA,
+		WRITE_STATIC_DATA,
+		UPLOAD_FILES,
+	},
+	REVIEWER: {
+		READ_USERS,
+		READ_TASKS,
+		READ_SUBMISSIONS,
+		REVIEW_SUBMISSIONS,
+		READ_MENTORS,
+		READ_STATIC_DATA,
+	},
+	CONTENT_EDITOR: {
+		READ_USERS,
+		READ_TASKS,
+		WRITE_TASKS,
+		READ_SUBMISSIONS,
+		READ_MENTORS,
+		WRITE_MENTORS,
+		READ_STATIC_DATA,
+		WRITE_STATIC_DATA,
+		UPLOAD_FILES,
+	},
+}
+
+// Permissions returns the permissions granted to the role, an unknown role has none
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+type TokenType string
+
+const (
+	ACCESS_TOKEN  TokenType = "access"
+	REFRESH_TOKEN TokenType = "refresh"
+	MFA_CHALLENGE TokenType = "mfa_challenge"
+)
+
+type LockoutScope string
+
+const (
+	LOCKOUT_USERNAME LockoutScope = "username"
+	LOCKOUT_IP       LockoutScope = "ip"
+)
+
+func (l LockoutScope) String() string {
+	switch l {
+	case LOCKOUT_USERNAME:
+		return "username"
+	case LOCKOUT_IP:
+		return "ip"
+	}
+	return ""
+}
+
+type SearchResultType string
+
+const (
+	STUDENT_RESULT SearchResultType = "student"
+	TASK_RESULT    SearchResultType = "task"
+	MENTOR_RESULT  SearchResultType = "mentor"
+)
+
+type StudentStatus string
+
+const (
+	STUDENT_ACTIVE      StudentStatus = "active"
+	STUDENT_DEACTIVATED StudentStatus = "deactivated"
+)
+
+func (s StudentStatus) String() string {
+	switch s {
+	case STUDENT_ACTIVE, "":
+		return "active"
+	case STUDENT_DEACTIVATED:
+		return "deactivated"
+	}
+	return ""
+}
+
+type FileFormat string
+
+const (
+	CSV  FileFormat = "csv"
+	XLSX FileFormat = "xlsx"
+)
+
+func (f FileFormat) String() string {
+	switch f {
+	case CSV:
+		return "csv"
+	case XLSX:
+		return "xlsx"
+	}
+	return ""
+}
+
+func (f FileFormat) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+type ImportRowStatus string
+
+const (
+	ROW_CREATED ImportRowStatus = "created"
+	ROW_VALID   ImportRowStatus = "valid" // passed validation in a dry run
+	ROW_SKIPPED ImportRowStatus = "skipped"
+	ROW_ERROR   ImportRowStatus = "error"
+)
+
+// Timeliness of a submission against its task's due date
+type Timeliness string
+
+const (
+	ON_TIME Timeliness = "on_time"
+	LATE    Timeliness = "late"
+)
+
+// TaskCascade decides what deleting a task does to its submissions
+type TaskCascade string
+
+const (
+	// archive the task only when it has no submissions
+	CASCADE_BLOCK TaskCascade = "block"
+	// archive the task together with its submissions
+	CASCADE_ARCHIVE TaskCascade = "archive"
+	// delete the task for good together with its submissions, archived ones included
+	CASCADE_FORCE TaskCascade = "force"
+)
+
+func (c TaskCascade) String() string {
+	switch c {
+	case CASCADE_BLOCK:
+		return "block"
+	case CASCADE_ARCHIVE:
+		return "archive"
+	case CASCADE_FORCE:
+		return "force"
+	}
+	return ""
+}
+
+// MentorDetach decides what deleting a mentor does to the students assigned to them
+type MentorDetach string
+
+const (
+	// archive the mentor only when no active students are assigned
+	DETACH_BLOCK MentorDetach = "block"
+	// archive the mentor and remove them from their students
+	DETACH_STUDENTS MentorDetach = "detach"
+)
+
+func (d MentorDetach) String() string {
+	switch d {
+	case DETACH_BLOCK:
+		return "block"
+	case DETACH_STUDENTS:
+		return "detach"
+	}
+	return ""
+}
+
+type SessionStatus string
+
+const (
+	SESSION_SCHEDULED SessionStatus = "scheduled"
+	SESSION_CANCELLED SessionStatus = "cancelled"
+)
+
+func (s SessionStatus) String() string {
+	switch s {
+	case SESSION_SCHEDULED:
+		return "scheduled"
+	case SESSION_CANCELLED:
+		return "cancelled"
+	}
+	return ""
+}
+
+// StaticDataKind names one of the static collections, the values are the collection names
+type StaticDataKind string
+
+const (
+	STATIC_DOMAINS  StaticDataKind = "domains"
+	STATIC_COLLEGES StaticDataKind = "colleges"
+	STATIC_COURSES  StaticDataKind = "courses"
+)
+
+func (k StaticDataKind) String() string {
+	switch k {
+	case STATIC_DOMAINS:
+		return "domains"
+	case STATIC_COLLEGES:
+		return "colleges"
+	case STATIC_COURSES:
+		return "courses"
 	}
 	return ""
 }
@@ -1,23 +1,137 @@
 package models
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type MentorDTO struct {
-	Id           string   `json:"_id"`
-	Name         string   `validate:"required"`
-	Title        string   `validate:"required"`
-	Organization string   `validate:"required"`
-	Image        string   `validate:"required"`
-	Domain       string   `validate:"required"`
-	Videos       []Videos `json:"videos"`
+	Id           string `json:"_id"`
+	Name         string `validate:"required"`
+	Title        string `validate:"required"`
+	Organization string `validate:"required"`
+	Image        string `validate:"required"`
+	Domain       string `validate:"required"`
+	Capacity     int    `json:"capacity" validate:"min=0"`
 }
 
 type Videos struct {
-	ThumbUrl string `json:"thumbnail"`
-	VideoUrl string `json:"video"`
+	Id          primitive.ObjectID `json:"id" bson:"_id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	// length of the video in seconds
+	Duration  int                `json:"duration"`
+	Domain    string             `json:"domain"`
+	ThumbUrl  string             `json:"thumbnail"`
+	VideoUrl  string             `json:"video"`
+	CreatedAt primitive.DateTime `json:"created_at" bson:",omitempty"`
+}
+
+// VideoDTO is bound from json, or from a multipart form when the video file is uploaded
+type VideoDTO struct {
+	Title       string `json:"title" form:"title" validate:"required,max=200"`
+	Description string `json:"description" form:"description" validate:"max=2000"`
+	Duration    int    `json:"duration" form:"duration" validate:"required,min=1"`
+	// defaults to the mentor's domain
+	Domain   string `json:"domain" form:"domain"`
+	VideoUrl string `json:"video" form:"video" validate:"omitempty,url"`
+	ThumbUrl string `json:"thumbnail" form:"thumbnail" validate:"omitempty,url"`
+}
+
+func (v VideoDTO) Validate() error {
+	validate := validator.New()
+
+	return validate.Struct(v)
+}
+
+type AvailabilityDTO struct {
+	Slots []AvailabilitySlot `json:"slots" validate:"max=50,dive"`
+}
+
+const clockLayout = "15:04"
+
+func (a AvailabilityDTO) Validate() error {
+	validate := validator.New()
+
+	if err := validate.Struct(a); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAvailability, err)
+	}
+
+	for _, slot := range a.Slots {
+		if _, err := time.LoadLocation(slot.TimeZone); err != nil {
+			return fmt.Errorf("%w: unknown time zone %q", ErrInvalidAvailability, slot.TimeZone)
+		}
+
+		start, err := time.Parse(clockLayout, slot.Start)
+		if err != nil {
+			return fmt.Errorf("%w: start %q is not HH:MM", ErrInvalidAvailability, slot.Start)
+		}
+		end, err := time.Parse(clockLayout, slot.End)
+		if err != nil {
+			return fmt.Errorf("%w: end %q is not HH:MM", ErrInvalidAvailability, slot.End)
+		}
+
+		if !end.After(start) {
+			return fmt.Errorf("%w: slot on %s ends before it starts", ErrInvalidAvailability, slot.Weekday)
+		}
+	}
+
+	return nil
+}
+
+type SessionDTO struct {
+	MentorId  primitive.ObjectID `json:"mentor_id"`
+	StudentId primitive.ObjectID `json:"student_id"`
+	StartAt   time.Time          `json:"start_at"`
+	EndAt     time.Time          `json:"end_at"`
+	Topic     string             `json:"topic" validate:"max=500"`
+}
+
+func (s SessionDTO) Validate() error {
+	validate := validator.New()
+
+	if err := validate.Struct(s); err != nil {
+		return err
+	}
+
+	if s.MentorId.IsZero() || s.StudentId.IsZero() {
+		return fmt.Errorf("mentor_id and student_id are required")
+	}
+
+	if !s.EndAt.After(s.StartAt) || !s.StartAt.After(time.Now()) {
+		return ErrInvalidSessionTime
+	}
+
+	return nil
+}
+
+type SessionCancelDTO struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+func (s SessionCancelDTO) Validate() error {
+	validate := validator.New()
+
+	return validate.Struct(s)
+}
+
+type SessionFilter struct {
+	Pagination
+	MentorId  string        `query:"mentor_id"`
+	StudentId string        `query:"student_id"`
+	Status    SessionStatus `query:"status"`
+	// sessions starting within from and to
+	From time.Time `query:"from"`
+	To   time.Time `query:"to"`
+}
+
+type VideoOrderDTO struct {
+	VideoIds []primitive.ObjectID `json:"video_ids"`
 }
 
 func (mentor MentorDTO) Validate() error {
@@ -35,8 +149,32 @@ func (dto MentorDTO) ToMentor() Mentor {
 		Organization: dto.Organization,
 		Image:        dto.Image,
 		Domain:       dto.Domain,
-		Videos:       dto.Videos,
+		Capacity:     dto.Capacity,
+	}
+}
+
+// MentorAssignmentDTO selects students by id, or by domain and/or college as a cohort
+type MentorAssignmentDTO struct {
+	StudentIds []primitive.ObjectID `json:"student_ids" validate:"max=500"`
+	Domain     string               `json:"domain"`
+	College    string               `json:"college"`
+}
+
+func (a *MentorAssignmentDTO) Validate() error {
+	validate := validator.New()
+
+	if err := validate.Struct(a); err != nil {
+		return err
+	}
+
+	if len(a.StudentIds) == 0 && a.Domain == "" && a.College == "" {
+		return ErrEmptyMentorAssignment
+	}
+	if len(a.StudentIds) > 0 && (a.Domain != "" || a.College != "") {
+		return ErrEmptyMentorAssignment
 	}
+
+	return nil
 }
 
 type TaskSubmissionDTO struct {
@@ -46,26 +184,47 @@ type TaskSubmissionDTO struct {
 }
 
 type TaskDTO struct {
-	ID       string
-	Semester string `json:"semester" validate:"required"`
-	Domain   string `json:"domain" validate:"required"` // TYPE CAN BE RETAIL, ED-Tech
-	Title    string `json:"title" validate:"required"`  // title of task
-	Detail   string `json:"detail" validate:"required"`
+	ID         string
+	Semester   string         `json:"semester" validate:"required"`
+	Domain     string         `json:"domain" validate:"required"` // TYPE CAN BE RETAIL, ED-Tech
+	Title      string         `json:"title" validate:"required"`  // title of task
+	Detail     string         `json:"detail" validate:"required"`
+	OpenAt     *time.Time     `json:"open_at"`
+	DueAt      *time.Time     `json:"due_at"`
+	Assignment TaskAssignment `json:"assignment"`
 }
 
 func (tD TaskDTO) ToTask() Task {
-	return Task{
-		Semester: tD.Semester,
-		Domain:   tD.Domain,
-		Title:    tD.Title,
-		Detail:   tD.Detail,
+	task := Task{
+		Semester:   tD.Semester,
+		Domain:     tD.Domain,
+		Title:      tD.Title,
+		Detail:     tD.Detail,
+		Assignment: tD.Assignment,
+	}
+
+	if tD.OpenAt != nil {
+		task.OpenAt = primitive.NewDateTimeFromTime(*tD.OpenAt)
 	}
+	if tD.DueAt != nil {
+		task.DueAt = primitive.NewDateTimeFromTime(*tD.DueAt)
+	}
+
+	return task
 }
 
 func (task *TaskDTO) Validate() error {
 	validate := validator.New()
 
-	return validate.Struct(task)
+	if err := validate.Struct(task); err != nil {
+		return err
+	}
+
+	if task.OpenAt != nil && task.DueAt != nil && !task.DueAt.After(*task.OpenAt) {
+		return ErrInvalidTaskDates
+	}
+
+	return nil
 }
 
 type StudentDTO struct {
@@ -82,7 +241,7 @@ type StudentDTO struct {
 	College          string   `json:"college" validate:"required"`
 	Course           string   `json:"course" validate:"required"`
 	Specialization   string   `json:"specialization" validate:"required"`
-	HasArrears       bool     `json:"has_arrears" validate:"required"`
+	HasArrears       bool     `json:"has_arrears"`
 	Place            string   `json:"place" validate:"required"`
 	Semester         string   `json:"semester" validate:"required"`
 	District         string   `json:"district" validate:"required"`
@@ -98,6 +257,13 @@ func (Student *StudentDTO) Validate() error {
 	return validate.Struct(Student)
 }
 
+// ValidateUpdate validates an admin edit, the password can not be changed through it
+func (Student *StudentDTO) ValidateUpdate() error {
+	validate := validator.New()
+
+	return validate.StructExcept(Student, "Password")
+}
+
 func (stu StudentDTO) ToStudent() Student {
 	return Student{
 		Email:            stu.Email,
@@ -124,6 +290,282 @@ func (stu StudentDTO) ToStudent() Student {
 	}
 }
 
+type SubmissionReviewDTO struct {
+	SubmissionId string `json:"task_id" validate:"required"`
+	Status       Status `json:"status" validate:"required,oneof=active completed inactive rejected needs_revision"`
+	Feedback     string `json:"feedback"`
+}
+
+func (review *SubmissionReviewDTO) Validate() error {
+	validate := validator.New()
+
+	if err := validate.Struct(review); err != nil {
+		return err
+	}
+
+	return validateReviewFeedback(review.Status, review.Feedback)
+}
+
+type BulkSubmissionReviewDTO struct {
+	SubmissionIds []string `json:"submission_ids" validate:"required,min=1,max=200,dive,required"`
+	Status        Status   `json:"status" validate:"required,oneof=active completed inactive rejected needs_revision"`
+	Feedback      string   `json:"feedback"`
+}
+
+func (review *BulkSubmissionReviewDTO) Validate() error {
+	validate := validator.New()
+
+	if err := validate.Struct(review); err != nil {
+		return err
+	}
+
+	return validateReviewFeedback(review.Status, review.Feedback)
+}
+
+func validateReviewFeedback(status Status, feedback string) error {
+	if status == REJECTED && strings.TrimSpace(feedback) == "" {
+		return ErrFeedbackRequired
+	}
+	return nil
+}
+
 type TokenDto struct {
 	Token string
 }
+
+type AdminDTO struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+	Role     Role   `json:"role" validate:"required,oneof=superadmin reviewer content_editor"`
+}
+
+func (admin *AdminDTO) Validate() error {
+	validate := validator.New()
+
+	return validate.Struct(admin)
+}
+
+type Pagination struct {
+	Page  int64 `query:"page"`
+	Limit int64 `query:"limit"`
+}
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Normalize applies the default page size and caps it, pages start at 1
+func (p *Pagination) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = defaultPageLimit
+	}
+	if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+}
+
+func (p Pagination) Skip() int64 {
+	return (p.Page - 1) * p.Limit
+}
+
+type AuditFilter struct {
+	Pagination
+	ActorId    string    `query:"actor"`
+	Collection string    `query:"collection"`
+	EntityId   string    `query:"entity_id"`
+	From       time.Time `query:"from"`
+	To         time.Time `query:"to"`
+}
+
+type StudentFilter struct {
+	Pagination
+	Sort        string    `query:"sort"`
+	Order       string    `query:"order"`
+	College     string    `query:"college"`
+	Course      string    `query:"course"`
+	Semester    string    `query:"semester"`
+	Domain      string    `query:"domain"`
+	District    string    `query:"district"`
+	State       string    `query:"state"`
+	HasArrears  *bool     `query:"has_arrears"`
+	CreatedFrom time.Time `query:"created_from"`
+	CreatedTo   time.Time `query:"created_to"`
+	Status      string    `query:"status"` // active by default, deactivated or all
+}
+
+// sortable student fields, json name to bson name
+var studentSortFields = map[string]string{
+	"created_at": "createdat",
+	"first_name": "firstname",
+	"last_name":  "lastname",
+	"email":      "email",
+	"college":    "college",
+	"course":     "course",
+	"semester":   "semester",
+	"district":   "district",
+	"state":      "state",
+}
+
+func (f StudentFilter) Validate() error {
+	if _, ok := studentSortFields[f.Sort]; f.Sort != "" && !ok {
+		return ErrInvalidSortField
+	}
+	if f.Order != "" && f.Order != "asc" && f.Order != "desc" {
+		return ErrInvalidSortOrder
+	}
+	if f.Status != "" && f.Status != "all" && StudentStatus(f.Status).String() == "" {
+		return ErrInvalidStudentStatus
+	}
+	return nil
+}
+
+// SortField returns the bson field to sort by and the direction, by default in insertion order
+func (f StudentFilter) SortField() (string, int) {
+	field, ok := studentSortFields[f.Sort]
+	if !ok {
+		field = "_id"
+	}
+
+	if f.Order == "desc" {
+		return field, -1
+	}
+	return field, 1
+}
+
+type SubmissionFilter struct {
+	Pagination
+	Sort     string    `query:"sort"`
+	Order    string    `query:"order"`
+	Status   Status    `query:"status"`
+	TaskId   string    `query:"task_id"`
+	Domain   string    `query:"domain"`
+	Semester string    `query:"semester"`
+	College  string    `query:"college"`
+	From     time.Time `query:"from"` // on the last update of the submission
+	To       time.Time `query:"to"`
+	// submissions archived with their task are left out unless asked for
+	IncludeArchived bool `query:"include_archived"`
+}
+
+// sortable submission fields, json name to bson name
+var submissionSortFields = map[string]string{
+	"updated_at": "updatedat",
+	"created_at": "createdat",
+	"status":     "status",
+}
+
+func (f SubmissionFilter) Validate() error {
+	if _, ok := submissionSortFields[f.Sort]; f.Sort != "" && !ok {
+		return ErrInvalidSortField
+	}
+	if f.Order != "" && f.Order != "asc" && f.Order != "desc" {
+		return ErrInvalidSortOrder
+	}
+	if f.Status != "" && f.Status.String() == "" {
+		return ErrInvalidSubmissionStatus
+	}
+	if f.TaskId != "" && !primitive.IsValidObjectID(f.TaskId) {
+		return ErrInvalidTaskId
+	}
+	return nil
+}
+
+// SortField returns the bson field to sort by and the direction, by default in insertion order
+func (f SubmissionFilter) SortField() (string, int) {
+	field, ok := submissionSortFields[f.Sort]
+	if !ok {
+		field = "_id"
+	}
+
+	if f.Order == "desc" {
+		return field, -1
+	}
+	return field, 1
+}
+
+// StudentDTOFromRow maps an import row to a StudentDTO, the header uses the json names of
+// StudentDTO. Domains are separated by semicolons, gender is male/female or 1/2.
+func StudentDTOFromRow(header []string, row []string) (StudentDTO, error) {
+	dto := StudentDTO{}
+
+	for i, column := range header {
+		if i >= len(row) {
+			break
+		}
+		value := strings.TrimSpace(row[i])
+
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "email":
+			dto.Email = strings.ToLower(value)
+		case "first_name":
+			dto.FirstName = value
+		case "last_name":
+			dto.LastName = value
+		case "middle_name":
+			dto.MiddleName = value
+		case "domains":
+			for _, domain := range strings.Split(value, ";") {
+				if domain = strings.TrimSpace(domain); domain != "" {
+					dto.Domains = append(dto.Domains, domain)
+				}
+			}
+		case "password":
+			dto.Password = value
+		case "dob":
+			dto.DOB = value
+		case "gender":
+			switch strings.ToLower(value) {
+			case "male", "1":
+				dto.Gender = MALE
+			case "female", "2":
+				dto.Gender = FEMALE
+			case "":
+			default:
+				return dto, fmt.Errorf("invalid gender %q", value)
+			}
+		case "phone_number":
+			dto.PhoneNumber = value
+		case "phone_number_alt":
+			dto.PhoneNumberAlt = value
+		case "college":
+			dto.College = value
+		case "course":
+			dto.Course = value
+		case "specialization":
+			dto.Specialization = value
+		case "has_arrears":
+			switch strings.ToLower(value) {
+			case "yes", "y":
+				dto.HasArrears = true
+			case "no", "n", "":
+				dto.HasArrears = false
+			default:
+				hasArrears, err := strconv.ParseBool(value)
+				if err != nil {
+					return dto, fmt.Errorf("invalid has_arrears %q", value)
+				}
+				dto.HasArrears = hasArrears
+			}
+		case "place":
+			dto.Place = value
+		case "semester":
+			dto.Semester = value
+		case "district":
+			dto.District = value
+		case "state":
+			dto.State = value
+		case "country":
+			dto.Country = value
+		case "date_of_joining":
+			dto.DateOfJoining = value
+		case "course_ending_date":
+			dto.CourseEndingDate = value
+		}
+	}
+
+	return dto, nil
+}
@@ -1,16 +1,136 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 var ErrStudentExists = fmt.Errorf("student already exists")
 var ErrInvalidCredentials = fmt.Errorf("invalid credentials")
 var ErrNoStudentExists = fmt.Errorf("no student with given studentname")
 var ErrNoStudentWithIdExists = fmt.Errorf("no Student with id exists")
+var ErrInvalidStudentStatus = fmt.Errorf("invalid student status")
+var ErrStudentEmailTaken = fmt.Errorf("email is used by another student")
+var ErrSubmissionModified = fmt.Errorf("submission was modified by another review, reload and try again")
+var ErrInvalidSubmissionStatus = fmt.Errorf("invalid submission status")
+var ErrInvalidAssignment = fmt.Errorf("invalid task assignment")
+var ErrEmptyMentorAssignment = fmt.Errorf("select students by student_ids or by domain and/or college")
+var ErrInvalidCohort = fmt.Errorf("invalid cohort")
+var ErrTaskArchived = fmt.Errorf("task is archived")
+var ErrTaskNotArchived = fmt.Errorf("task is not archived")
+var ErrSubmissionArchived = fmt.Errorf("submission is archived")
+var ErrInvalidCascade = fmt.Errorf("cascade must be block, archive or force")
+var ErrTaskModified = fmt.Errorf("task was modified by another edit, reload and try again")
+var ErrInvalidTaskDates = fmt.Errorf("due_at must be after open_at")
+var ErrInvalidTaskId = fmt.Errorf("invalid task id")
+var ErrFeedbackRequired = fmt.Errorf("feedback is required when rejecting a submission")
+var ErrEmptyImport = fmt.Errorf("import file has no rows")
 var ErrParsingStudent = fmt.Errorf("error parsing student data from database")
 
 var ErrNoAdminWithUsername = fmt.Errorf("no admin with username exists")
+var ErrNoAdminWithId = fmt.Errorf("no admin with id exists")
+var ErrAdminExists = fmt.Errorf("admin already exists")
+var ErrAdminDisabled = fmt.Errorf("admin account is disabled")
+var ErrInvalidToken = fmt.Errorf("invalid token")
+var ErrTokenRevoked = fmt.Errorf("token has been revoked")
+var ErrTOTPAlreadyEnabled = fmt.Errorf("two-factor authentication is already enabled")
+var ErrTOTPNotEnabled = fmt.Errorf("two-factor authentication is not enabled")
+var ErrTOTPEnrolmentNotStarted = fmt.Errorf("two-factor enrolment has not been started")
+var ErrInvalidTOTPCode = fmt.Errorf("invalid two-factor code")
+var ErrWeakPassword = fmt.Errorf("password must be at least 8 characters long")
+var ErrInvalidResetToken = fmt.Errorf("invalid or expired password reset token")
+var ErrCannotModifySelf = fmt.Errorf("admins cannot disable or delete their own account")
 
 var ErrMentorExists = fmt.Errorf("mentor already exists")
+var ErrMentorArchived = fmt.Errorf("mentor is archived")
+var ErrInvalidDetach = fmt.Errorf("students must be block or detach")
+var ErrMentorModified = fmt.Errorf("mentor videos were modified by another edit, reload and try again")
+var ErrVideoNotFound = fmt.Errorf("no video with id exists for mentor")
+var ErrVideoRequired = fmt.Errorf("upload a video file or give a video url")
+var ErrInvalidVideoDomain = fmt.Errorf("video domain does not exist")
+var ErrInvalidAvailability = fmt.Errorf("invalid availability")
+var ErrInvalidSessionTime = fmt.Errorf("end_at must be after start_at and start_at must be in the future")
+var ErrOutsideAvailability = fmt.Errorf("session is outside the mentor's availability")
+var ErrSessionNotScheduled = fmt.Errorf("session is not scheduled")
+var ErrStudentDeactivated = fmt.Errorf("student is deactivated")
+var ErrInvalidVideoOrder = fmt.Errorf("video_ids must list every video of the mentor exactly once")
+
+var ErrStaticDataExists = fmt.Errorf("an entry with that name already exists")
+
+var ErrInvalidSortField = fmt.Errorf("invalid sort field")
+var ErrInvalidSortOrder = fmt.Errorf("invalid sort order, use asc or desc")
 
 var ErrNoValidRecordFound = fmt.Errorf("no valid document found")
 var ErrTaskSubmissionExists = fmt.Errorf("task submission already exists")
+
+// LoginLockedError is returned while a username or ip is locked out after repeated failed logins
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %d seconds", int64(e.RetryAfter.Seconds()))
+}
+
+// InvalidTransitionError is returned when a review would move a submission to a status that
+// is not allowed from its current one
+type InvalidTransitionError struct {
+	From    Status   `json:"from"`
+	To      Status   `json:"to"`
+	Allowed []Status `json:"allowed"`
+}
+
+func (e InvalidTransitionError) Error() string {
+	return fmt.Sprintf("submission can not move from %q to %q", e.From, e.To)
+}
+
+// TaskHasSubmissionsError is returned when a task with submissions is deleted with the block
+// cascade
+type TaskHasSubmissionsError struct {
+	Submissions int64 `json:"submissions"`
+}
+
+func (e TaskHasSubmissionsError) Error() string {
+	return fmt.Sprintf("task has %d submissions, delete it with cascade archive or force", e.Submissions)
+}
+
+// MentorCapacityError is returned when an assignment would take a mentor over capacity
+type MentorCapacityError struct {
+	Capacity  int   `json:"capacity"`
+	Assigned  int64 `json:"assigned"`
+	Requested int64 `json:"requested"`
+}
+
+func (e MentorCapacityError) Error() string {
+	return fmt.Sprintf("mentor has %d of %d students assigned, %d more do not fit", e.Assigned, e.Capacity, e.Requested)
+}
+
+// MentorHasStudentsError is returned when a mentor with active students is deleted without
+// detaching them
+type MentorHasStudentsError struct {
+	Students []StudentResponse `json:"students"`
+}
+
+func (e MentorHasStudentsError) Error() string {
+	return fmt.Sprintf("mentor is assigned to %d students, delete it with students=detach", len(e.Students))
+}
+
+// SessionConflictError is returned when a session overlaps a scheduled session of the same
+// mentor or student
+type SessionConflictError struct {
+	Sessions []MentorSession `json:"sessions"`
+}
+
+func (e SessionConflictError) Error() string {
+	return fmt.Sprintf("session overlaps %d scheduled sessions", len(e.Sessions))
+}
+
+// StaticDataInUseError is returned when a domain, college or course that is still referenced
+// is deleted
+type StaticDataInUseError struct {
+	References map[string]int64 `json:"references"`
+}
+
+func (e StaticDataInUseError) Error() string {
+	return "entry is still referenced, rename it or move the references first"
+}
@@ -1,6 +1,43 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ErrorResponse struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type LoginLockout struct {
+	Scope      LockoutScope `json:"scope"`
+	Key        string       `json:"key"`
+	Failures   int64        `json:"failures"`
+	RetryAfter int64        `json:"retry_after"`
+}
+
+type PasswordResetResponse struct {
+	Token     string             `json:"token"`
+	ExpiresAt primitive.DateTime `json:"expires_at"`
+}
+
+type PageResponse struct {
+	Data  interface{} `json:"data"`
+	Total int64       `json:"total"`
+	Page  int64       `json:"page"`
+	Limit int64       `json:"limit"`
+}
 
 type StudentResponse struct {
 	ID               primitive.ObjectID `json:"id"`
@@ -26,6 +63,7 @@ type StudentResponse struct {
 	Country          string             `json:"country"`
 	DateOfJoining    string             `json:"date_of_joining"`
 	CourseEndingDate string             `json:"course_ending_date"`
+	Status           string             `json:"status"`
 }
 
 type MentorResponse struct {
@@ -37,6 +75,9 @@ type MentorResponse struct {
 	CreatedAt    primitive.DateTime `json:"created_at"`
 	Image        string             `json:"image"`
 	Videos       []Videos           `json:"videos,omitempty"`
+	Capacity     int                `json:"capacity"`
+	ArchivedAt   primitive.DateTime `json:"archived_at,omitempty"`
+	Availability []AvailabilitySlot `json:"availability"`
 }
 
 type TaskStudentResponse struct {
@@ -63,6 +104,11 @@ type TaskSubmissionsAdminResponse struct {
 	Comment   string             `json:"comment"`
 	Task      Task               `json:"task"`
 	Student   StudentTaskRespone `json:"student"`
+	Reviews   []SubmissionReview `json:"reviews"`
+	// empty when the task has no due date
+	Timeliness Timeliness `json:"timeliness,omitempty"`
+	// zero for submissions made before tasks were versioned
+	TaskVersion int `json:"task_version,omitempty" bson:"taskversion"`
 }
 
 type Data struct {
@@ -70,3 +116,170 @@ type Data struct {
 	Colleges []string `json:"colleges"`
 	Courses  []string `json:"courses"`
 }
+
+type AdminResponse struct {
+	ID          primitive.ObjectID `json:"_id"`
+	Username    string             `json:"username"`
+	Role        Role               `json:"role"`
+	Disabled    bool               `json:"disabled"`
+	TOTPEnabled bool               `json:"totp_enabled"`
+	CreatedAt   primitive.DateTime `json:"created_at"`
+	UpdatedAt   primitive.DateTime `json:"updated_at"`
+}
+
+// LoginResponse carries either the token pair or, for admins with two-factor
+// authentication, the challenge token to exchange together with a TOTP code
+type LoginResponse struct {
+	*TokenPair
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+}
+
+type TOTPEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type SearchResult struct {
+	Type     SearchResultType   `json:"type"`
+	ID       primitive.ObjectID `json:"_id"`
+	Title    string             `json:"title"`
+	Subtitle string             `json:"subtitle"`
+	Score    float64            `json:"score"`
+}
+
+type ImportRowResult struct {
+	Row    int             `json:"row"`
+	Email  string          `json:"email"`
+	Status ImportRowStatus `json:"status"`
+	Errors []string        `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Errored int               `json:"errored"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+type BulkReviewResult struct {
+	SubmissionId primitive.ObjectID `json:"submission_id"`
+	Reviewed     bool               `json:"reviewed"`
+	Error        string             `json:"error,omitempty"`
+}
+
+type BulkReviewReport struct {
+	Reviewed int                `json:"reviewed"`
+	Failed   int                `json:"failed"`
+	Results  []BulkReviewResult `json:"results"`
+}
+
+type TaskAudienceMember struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Email     string             `json:"email"`
+	FirstName string             `json:"first_name" bson:"firstname"`
+	LastName  string             `json:"last_name" bson:"lastname"`
+	College   string             `json:"college"`
+	Course    string             `json:"course"`
+	// inactive when the student has not submitted the task
+	SubmissionStatus Status `json:"submission_status" bson:"submissionstatus"`
+}
+
+type TaskAudienceResponse struct {
+	TaskId    primitive.ObjectID   `json:"task_id"`
+	Total     int                  `json:"total"`
+	Submitted int                  `json:"submitted"`
+	Students  []TaskAudienceMember `json:"students"`
+}
+
+type TaskDeleteReport struct {
+	TaskId  primitive.ObjectID `json:"task_id"`
+	Cascade TaskCascade        `json:"cascade"`
+	// archived or deleted
+	Task                string `json:"task"`
+	SubmissionsArchived int64  `json:"submissions_archived"`
+	// submissions deleted along with the task by a forced delete
+	SubmissionsDeleted int64 `json:"submissions_deleted"`
+}
+
+type TaskRestoreReport struct {
+	TaskId              primitive.ObjectID `json:"task_id"`
+	SubmissionsRestored int64              `json:"submissions_restored"`
+}
+
+type MentorStudentsResponse struct {
+	MentorId primitive.ObjectID `json:"mentor_id"`
+	Capacity int                `json:"capacity"`
+	Total    int                `json:"total"`
+	Students []StudentResponse  `json:"students"`
+}
+
+type MentorAssignmentReport struct {
+	MentorId primitive.ObjectID `json:"mentor_id"`
+	// students assigned or unassigned by this request
+	Changed int64 `json:"changed"`
+	// students assigned to the mentor afterwards
+	Assigned int64 `json:"assigned"`
+	Capacity int   `json:"capacity"`
+}
+
+// MentorVideo is a video listed together with the mentor it belongs to
+type MentorVideo struct {
+	Videos     `bson:",inline"`
+	MentorId   primitive.ObjectID `json:"mentor_id" bson:"mentor_id"`
+	MentorName string             `json:"mentor_name" bson:"mentor_name"`
+}
+
+type StaticRenameReport struct {
+	Kind StaticDataKind `json:"kind"`
+	From string         `json:"from"`
+	To   string         `json:"to"`
+	// documents updated per referencing field, eg students or tasks
+	Updated map[string]int64 `json:"updated"`
+}
+
+type MentorDeleteReport struct {
+	MentorId primitive.ObjectID `json:"mentor_id"`
+	Detach   MentorDetach       `json:"detach"`
+	// students the mentor was removed from, deactivated ones included
+	StudentsDetached int64 `json:"students_detached"`
+}
+
+// StudentExportHeader uses the import column names so an export can be edited and imported again
+var StudentExportHeader = []string{
+	"id", "email", "first_name", "middle_name", "last_name", "domains", "dob", "gender",
+	"phone_number", "phone_number_alt", "college", "course", "specialization", "has_arrears",
+	"place", "semester", "district", "state", "country", "date_of_joining", "course_ending_date",
+	"status", "created_at",
+}
+
+func (stu StudentResponse) ExportRow() []string {
+	return []string{
+		stu.ID.Hex(), stu.Email, stu.FirstName, stu.MiddleName, stu.LastName,
+		strings.Join(stu.Domains, ";"), stu.DOB, stu.Gender, stu.PhoneNumber, stu.PhoneNumberAlt,
+		stu.College, stu.Course, stu.Specialization, strconv.FormatBool(stu.HasArrears),
+		stu.Place, stu.Semester, stu.District, stu.State, stu.Country, stu.DateOfJoining,
+		stu.CourseEndingDate, stu.Status, formatDateTime(stu.CreatedAt),
+	}
+}
+
+var TaskSubmissionExportHeader = []string{
+	"id", "student_id", "student_email", "task_id", "task_title", "domain", "semester",
+	"status", "timeliness", "comment", "file_url", "updated_at",
+}
+
+func (sub TaskSubmissionsAdminResponse) ExportRow() []string {
+	return []string{
+		sub.ID.Hex(), sub.Student.Id.Hex(), sub.Student.Email, sub.Task.Id.Hex(), sub.Task.Title,
+		sub.Task.Domain, sub.Task.Semester, sub.Status.String(), string(sub.Timeliness), sub.Comment, sub.FileURL,
+		formatDateTime(sub.UpdatedAt),
+	}
+}
+
+func formatDateTime(d primitive.DateTime) string {
+	if d == 0 {
+		return ""
+	}
+	return d.Time().UTC().Format(time.RFC3339)
+}
@@ -2,6 +2,7 @@ package admin_repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/asishshaji/admin-api/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,18 +12,79 @@ type IAdminRepository interface {
 	GenerateAdminCredentials(ctx context.Context, username, password string) error
 
 	GetAdmin(ctx context.Context, username string) (*models.Admin, error)
+	GetAdminById(ctx context.Context, id primitive.ObjectID) (*models.Admin, error)
+	CreateAdmin(ctx context.Context, admin models.Admin) error
+	GetAdmins(ctx context.Context) ([]models.Admin, error)
+	SetAdminDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) error
+	DeleteAdmin(ctx context.Context, id primitive.ObjectID) error
+	UpdateAdminPassword(ctx context.Context, id primitive.ObjectID, password string) error
+	SetAdminTokensValidAfter(ctx context.Context, id primitive.ObjectID, at primitive.DateTime) error
+	CreatePasswordReset(ctx context.Context, reset models.PasswordReset) error
+	UsePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	SetPendingTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error
+	EnableTOTP(ctx context.Context, id primitive.ObjectID, secret string, step int64, recoveryCodes []string) error
+	DisableTOTP(ctx context.Context, id primitive.ObjectID) error
+	SetTOTPLastStep(ctx context.Context, id primitive.ObjectID, step int64) error
+	RemoveRecoveryCode(ctx context.Context, id primitive.ObjectID, recoveryCode string) error
+
 	AddTask(ctx context.Context, task models.Task) error
-	UpdateTask(ctx context.Context, task models.Task) error
+	UpdateTask(ctx context.Context, task models.Task, version int) error
+	CreateTaskVersion(ctx context.Context, version models.TaskVersion) error
+	GetTaskVersions(ctx context.Context, taskId primitive.ObjectID) ([]models.TaskVersion, error)
+	GetTaskById(ctx context.Context, taskId primitive.ObjectID) (*models.Task, error)
 	DeleteTask(ctx context.Context, taskId primitive.ObjectID) error
-	GetTasks(ctx context.Context) ([]models.Task, error)
-	GetUsers(ctx context.Context) (models.Students, error)
-	GetTaskSubmissions(c context.Context) ([]models.TaskSubmissionsAdminResponse, error)
+	GetTasks(ctx context.Context, includeArchived bool) ([]models.Task, error)
+	ArchiveTask(ctx context.Context, taskId primitive.ObjectID, at primitive.DateTime) error
+	RestoreTask(ctx context.Context, taskId primitive.ObjectID) error
+	CountTaskSubmissions(ctx context.Context, taskId primitive.ObjectID) (int64, error)
+	ArchiveTaskSubmissions(ctx context.Context, taskId primitive.ObjectID, at primitive.DateTime) (int64, error)
+	DeleteTaskSubmissions(ctx context.Context, taskId primitive.ObjectID) (int64, error)
+	RestoreTaskSubmissions(ctx context.Context, taskId primitive.ObjectID, at primitive.DateTime) (int64, error)
+	GetUsers(ctx context.Context, filter models.StudentFilter) (models.Students, int64, error)
+	StreamUsers(ctx context.Context, filter models.StudentFilter, fn func(models.Student) error) error
+	CreateStudent(ctx context.Context, student models.Student) error
+	GetStudentById(ctx context.Context, id primitive.ObjectID) (*models.Student, error)
+	GetStudentByEmail(ctx context.Context, email string) (*models.Student, error)
+	UpdateStudent(ctx context.Context, student models.Student) error
+	DeactivateStudent(ctx context.Context, id primitive.ObjectID) error
+	GetTasksDueForReminder(ctx context.Context, from, to time.Time) ([]models.Task, error)
+	ClaimTaskReminder(ctx context.Context, task models.Task) (bool, error)
+	ReleaseTaskReminder(ctx context.Context, task models.Task) error
+	GetTaskAudience(ctx context.Context, task models.Task) ([]models.TaskAudienceMember, error)
+	GetPendingStudentsForTask(ctx context.Context, task models.Task) ([]models.Student, error)
+	GetTaskSubmissions(c context.Context, filter models.SubmissionFilter) ([]models.TaskSubmissionsAdminResponse, int64, error)
+	StreamTaskSubmissions(c context.Context, filter models.SubmissionFilter, fn func(models.TaskSubmissionsAdminResponse) error) error
 	GetTaskSubmissionsForUser(c context.Context, userid primitive.ObjectID) ([]models.TaskSubmissionsAdminResponse, error)
-	EditTaskSubmissionStatus(c context.Context, status models.Status, taskid primitive.ObjectID) error
+	GetTaskSubmissionById(c context.Context, id primitive.ObjectID) (*models.TaskSubmission, error)
+	ReviewTaskSubmission(c context.Context, id primitive.ObjectID, review models.SubmissionReview) error
 
 	CreateMentor(c context.Context, mentor models.Mentor) error
 	UpdateMentor(c context.Context, mentor models.Mentor) error
-	GetMentors(c context.Context) ([]models.Mentor, error)
+	GetMentors(c context.Context, includeArchived bool) ([]models.Mentor, error)
+	ArchiveMentor(c context.Context, mentorId primitive.ObjectID, at primitive.DateTime) error
+	DetachMentor(c context.Context, mentorId primitive.ObjectID) (int64, error)
+	AddMentorVideo(c context.Context, mentorId primitive.ObjectID, video models.Videos) error
+	RemoveMentorVideo(c context.Context, mentorId, videoId primitive.ObjectID) error
+	SetMentorVideos(c context.Context, mentorId primitive.ObjectID, expected []primitive.ObjectID, videos []models.Videos) error
+	GetVideos(c context.Context, domain string) ([]models.MentorVideo, error)
+	BackfillMentorVideos(c context.Context) error
+	SetMentorAvailability(c context.Context, mentorId primitive.ObjectID, slots []models.AvailabilitySlot) error
+
+	CreateSession(c context.Context, session models.MentorSession) error
+	DeleteSession(c context.Context, sessionId primitive.ObjectID) error
+	GetSessionConflicts(c context.Context, session models.MentorSession) ([]models.MentorSession, error)
+	GetSessionById(c context.Context, sessionId primitive.ObjectID) (*models.MentorSession, error)
+	CancelSession(c context.Context, sessionId primitive.ObjectID, reason string) error
+	GetSessions(c context.Context, filter models.SessionFilter) ([]models.MentorSession, int64, error)
+	CountMentorStudents(c context.Context, mentorId primitive.ObjectID) (int64, error)
+	GetUnassignedStudentIds(c context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) ([]primitive.ObjectID, error)
+	ReserveMentorSeats(c context.Context, mentorId primitive.ObjectID, n int64) (bool, error)
+	AdjustMentorAssigned(c context.Context, mentorId primitive.ObjectID, delta int64) error
+	BackfillMentorAssigned(c context.Context) error
+	AssignMentor(c context.Context, mentorId primitive.ObjectID, studentIds []primitive.ObjectID) (int64, error)
+	UnassignMentor(c context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (int64, int64, error)
+	GetMentorStudents(c context.Context, mentorId primitive.ObjectID) (models.Students, error)
+	GetMentorById(c context.Context, id primitive.ObjectID) (*models.Mentor, error)
 
 	CreateDomain(c context.Context, domain models.StaticModel) error
 	CreateCollege(c context.Context, college models.StaticModel) error
@@ -32,6 +94,20 @@ type IAdminRepository interface {
 	GetDomains(ctx context.Context) ([]models.StaticModel, error)
 	GetColleges(ctx context.Context) ([]models.StaticModel, error)
 	GetCourses(ctx context.Context) ([]models.StaticModel, error)
+	GetDomain(c context.Context, name string) (*models.StaticModel, error)
+	GetCollege(c context.Context, name string) (*models.StaticModel, error)
+	GetCourse(c context.Context, name string) (*models.StaticModel, error)
+	GetStaticData(c context.Context, kind models.StaticDataKind) ([]models.StaticModel, error)
+	FindStaticData(c context.Context, kind models.StaticDataKind, name string) (*models.StaticModel, error)
+	RenameStaticData(c context.Context, kind models.StaticDataKind, from, to string) error
+	PropagateStaticRename(c context.Context, kind models.StaticDataKind, from, to string) (map[string]int64, error)
+	CountStaticReferences(c context.Context, kind models.StaticDataKind, name string) (map[string]int64, error)
+	DeleteStaticData(c context.Context, kind models.StaticDataKind, name string) error
 
 	CreateNotification(ctx context.Context, notification models.NotificationEntity) error
+
+	Search(ctx context.Context, query string, limit int64) ([]models.SearchResult, error)
+
+	CreateAuditEntry(ctx context.Context, entry models.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, int64, error)
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/asishshaji/admin-api/models"
@@ -28,6 +29,10 @@ type AdminRepository struct {
 	tokenCollection          *mongo.Collection
 	notificationCollection   *mongo.Collection
 	courseCollection         *mongo.Collection
+	passwordResetCollection  *mongo.Collection
+	auditCollection          *mongo.Collection
+	taskVersionCollection    *mongo.Collection
+	sessionCollection        *mongo.Collection
 }
 
 func NewAdminRepository(l *log.Logger, db *mongo.Database) IAdminRepository {
@@ -45,17 +50,26 @@ func NewAdminRepository(l *log.Logger, db *mongo.Database) IAdminRepository {
 		collegeCollection:        db.Collection("colleges"),
 		courseCollection:         db.Collection("courses"),
 		notificationCollection:   db.Collection("notifications"),
+		passwordResetCollection:  db.Collection("admin_password_resets"),
+		auditCollection:          db.Collection("audit_log"),
+		taskVersionCollection:    db.Collection("task_versions"),
+		sessionCollection:        db.Collection("mentor_sessions"),
 	}
 }
 func (aR AdminRepository) GenerateAdminCredentials(ctx context.Context, username, password string) error {
 	opts := options.Update().SetUpsert(true)
 
+	// the password is only set when the admin is created, so a changed
+	// password is not reset to the environment value on every boot
 	res, err := aR.adminCollection.UpdateOne(ctx, bson.M{
 		"username": username,
 	}, bson.M{
 		"$set": bson.M{
-			"username": username,
-			"password": password,
+			"role": models.SUPERADMIN,
+		},
+		"$setOnInsert": bson.M{
+			"password":   password,
+			"created_at": primitive.NewDateTimeFromTime(time.Now()),
 		},
 	}, opts)
 
@@ -88,143 +102,1267 @@ func (adminRepo AdminRepository) GetAdmin(ctx context.Context, username string)
 	return admin, nil
 }
 
-func (aR AdminRepository) AddTask(ctx context.Context, task models.Task) error {
+func (aR AdminRepository) GetAdminById(ctx context.Context, id primitive.ObjectID) (*models.Admin, error) {
+	admin := new(models.Admin)
 
-	res, err := aR.taskCollection.InsertOne(ctx, task)
+	res := aR.adminCollection.FindOne(ctx, bson.M{"_id": id})
+
+	if res.Err() == mongo.ErrNoDocuments {
+		aR.l.Println("No admin with id", id.Hex(), "exists")
+		return nil, models.ErrNoAdminWithId
+	}
+
+	err := res.Decode(admin)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return admin, nil
+}
+
+func (aR AdminRepository) CreateAdmin(ctx context.Context, admin models.Admin) error {
+	res, err := aR.adminCollection.InsertOne(ctx, admin)
+
+	if mongo.IsDuplicateKeyError(err) {
+		aR.l.Println("admin already exists")
+		return models.ErrAdminExists
+	}
 
 	if err != nil {
 		aR.l.Println(err)
 		return err
 	}
 
-	aR.l.Println("Inserted task with ID", res.InsertedID)
+	aR.l.Println("Inserted admin with ID", res.InsertedID)
 
 	return nil
 }
 
-func (aR AdminRepository) UpdateTask(ctx context.Context, task models.Task) error {
-	opts := options.Update().SetUpsert(true)
+func (aR AdminRepository) GetAdmins(ctx context.Context) ([]models.Admin, error) {
+	admins := []models.Admin{}
 
-	up, err := utils.ToDoc(task)
+	cursor, err := aR.adminCollection.Find(ctx, bson.M{})
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if err = cursor.All(ctx, &admins); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return admins, nil
+}
 
+func (aR AdminRepository) SetAdminDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) error {
+	res, err := aR.adminCollection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"disabled":   disabled,
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	})
 	if err != nil {
+		aR.l.Println(err)
 		return err
 	}
 
-	doc := bson.M{"$set": up}
+	if res.MatchedCount == 0 {
+		return models.ErrNoAdminWithId
+	}
 
-	_, err = aR.taskCollection.UpdateByID(ctx, task.Id, doc, opts)
+	return nil
+}
+
+func (aR AdminRepository) DeleteAdmin(ctx context.Context, id primitive.ObjectID) error {
+	res, err := aR.adminCollection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		aR.l.Println(err)
 		return err
 	}
+
+	if res.DeletedCount == 0 {
+		return models.ErrNoAdminWithId
+	}
+
 	return nil
 }
 
-func (aR AdminRepository) GetTasks(ctx context.Context) ([]models.Task, error) {
-	tasks := []models.Task{}
+func (aR AdminRepository) updateAdmin(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	res, err := aR.adminCollection.UpdateByID(ctx, id, update)
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return models.ErrNoAdminWithId
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) UpdateAdminPassword(ctx context.Context, id primitive.ObjectID, password string) error {
+	return aR.updateAdmin(ctx, id, bson.M{
+		"$set": bson.M{
+			"password":   password,
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	})
+}
+
+func (aR AdminRepository) SetAdminTokensValidAfter(ctx context.Context, id primitive.ObjectID, at primitive.DateTime) error {
+	return aR.updateAdmin(ctx, id, bson.M{
+		"$set": bson.M{"tokens_valid_after": at},
+	})
+}
 
-	cursor, err := aR.taskCollection.Find(ctx, bson.M{})
+func (aR AdminRepository) CreatePasswordReset(ctx context.Context, reset models.PasswordReset) error {
+	res, err := aR.passwordResetCollection.InsertOne(ctx, reset)
 	if err != nil {
 		aR.l.Println(err)
-		return nil, err
+		return err
 	}
 
-	if err = cursor.All(ctx, &tasks); err != nil {
+	aR.l.Println("Inserted password reset with ID", res.InsertedID)
+
+	return nil
+}
+
+// UsePasswordReset marks an unused and unexpired reset token as used and returns it
+func (aR AdminRepository) UsePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	reset := new(models.PasswordReset)
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	res := aR.passwordResetCollection.FindOneAndUpdate(ctx, bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}, bson.M{
+		"$set": bson.M{"used_at": now},
+	})
+
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrInvalidResetToken
+	}
+
+	if err := res.Decode(reset); err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
 
-	return tasks, nil
+	return reset, nil
 }
 
-func (aR AdminRepository) CreateDomain(c context.Context, domain models.StaticModel) error {
-	return insertStaticModelData(c, aR.domainCollection, domain)
+func (aR AdminRepository) SetPendingTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error {
+	return aR.updateAdmin(ctx, id, bson.M{
+		"$set": bson.M{
+			"totp_pending_secret": secret,
+			"updated_at":          primitive.NewDateTimeFromTime(time.Now()),
+		},
+	})
 }
 
-func (aR AdminRepository) CreateNotification(ctx context.Context, notification models.NotificationEntity) error {
-	res, err := aR.notificationCollection.InsertOne(ctx, notification)
+func (aR AdminRepository) EnableTOTP(ctx context.Context, id primitive.ObjectID, secret string, step int64, recoveryCodes []string) error {
+	return aR.updateAdmin(ctx, id, bson.M{
+		"$set": bson.M{
+			"totp_enabled":   true,
+			"totp_secret":    secret,
+			"totp_last_step": step,
+			"recovery_codes": recoveryCodes,
+			"updated_at":     primitive.NewDateTimeFromTime(time.Now()),
+		},
+		"$unset": bson.M{
+			"totp_pending_secret": "",
+		},
+	})
+}
+
+func (aR AdminRepository) DisableTOTP(ctx context.Context, id primitive.ObjectID) error {
+	return aR.updateAdmin(ctx, id, bson.M{
+		"$set": bson.M{
+			"totp_enabled": false,
+			"updated_at":   primitive.NewDateTimeFromTime(time.Now()),
+		},
+		"$unset": bson.M{
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"totp_last_step":      "",
+			"recovery_codes":      "",
+		},
+	})
+}
+
+// SetTOTPLastStep only moves the step forward, so a code can not be used twice
+func (aR AdminRepository) SetTOTPLastStep(ctx context.Context, id primitive.ObjectID, step int64) error {
+	res, err := aR.adminCollection.UpdateOne(ctx, bson.M{
+		"_id":            id,
+		"totp_last_step": bson.M{"$lt": step},
+	}, bson.M{
+		"$set": bson.M{"totp_last_step": step},
+	})
 	if err != nil {
+		aR.l.Println(err)
 		return err
 	}
-	aR.l.Println("Inserted notification with id :> ", res.InsertedID)
+
+	if res.MatchedCount == 0 {
+		return models.ErrInvalidTOTPCode
+	}
+
 	return nil
 }
 
-func insertStaticModelData(c context.Context, collection *mongo.Collection, data models.StaticModel) error {
-	opts := options.Update().SetUpsert(true)
+func (aR AdminRepository) RemoveRecoveryCode(ctx context.Context, id primitive.ObjectID, recoveryCode string) error {
+	res, err := aR.adminCollection.UpdateByID(ctx, id, bson.M{
+		"$pull": bson.M{"recovery_codes": recoveryCode},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
 
-	_, err := collection.UpdateOne(c, bson.M{
-		"name": data.Name,
-	}, bson.M{
-		"$set": data,
-	}, opts)
+	if res.ModifiedCount == 0 {
+		return models.ErrInvalidTOTPCode
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) AddTask(ctx context.Context, task models.Task) error {
+
+	res, err := aR.taskCollection.InsertOne(ctx, task)
 
 	if err != nil {
+		aR.l.Println(err)
 		return err
 	}
+
+	aR.l.Println("Inserted task with ID", res.InsertedID)
+
 	return nil
+}
+
+// UpdateTask replaces the task as long as it is still at version, tasks saved before
+// versioning have no version and are matched by 0. Dates left out of the task are removed,
+// $set alone would keep the old ones since they are omitted when empty.
+func (aR AdminRepository) UpdateTask(ctx context.Context, task models.Task, version int) error {
+	up, err := utils.ToDoc(task)
+
+	if err != nil {
+		return err
+	}
+
+	doc := bson.M{"$set": up}
+
+	unset := bson.M{}
+	if task.OpenAt == 0 {
+		unset["openat"] = ""
+	}
+	if task.DueAt == 0 {
+		unset["dueat"] = ""
+		unset["reminderdueat"] = ""
+	}
+	if len(unset) > 0 {
+		doc["$unset"] = unset
+	}
+
+	filter := bson.M{"_id": task.Id, "version": version}
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	res, err := aR.taskCollection.UpdateOne(ctx, filter, doc)
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetTaskById(ctx, task.Id); err != nil {
+			return err
+		}
+		return models.ErrTaskModified
+	}
 
+	return nil
 }
 
-func (aR AdminRepository) CreateCollege(c context.Context, college models.StaticModel) error {
-	return insertStaticModelData(c, aR.collegeCollection, college)
+func (aR AdminRepository) CreateTaskVersion(ctx context.Context, version models.TaskVersion) error {
+	_, err := aR.taskVersionCollection.InsertOne(ctx, version)
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	return nil
 }
-func (aR AdminRepository) CreateCourse(c context.Context, course models.StaticModel) error {
-	return insertStaticModelData(c, aR.courseCollection, course)
+
+// GetTaskVersions returns the versions of a task, newest first
+func (aR AdminRepository) GetTaskVersions(ctx context.Context, taskId primitive.ObjectID) ([]models.TaskVersion, error) {
+	versions := []models.TaskVersion{}
+
+	opts := options.Find().SetSort(bson.M{"version": -1})
+
+	cursor, err := aR.taskVersionCollection.Find(ctx, bson.M{"task_id": taskId}, opts)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if err = cursor.All(ctx, &versions); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return versions, nil
 }
 
-func (aR AdminRepository) GetUsers(ctx context.Context) (models.Students, error) {
-	students := new([]models.Student)
-	cursor, err := aR.studentCollection.Find(ctx, bson.M{})
+func (aR AdminRepository) GetTaskById(ctx context.Context, taskId primitive.ObjectID) (*models.Task, error) {
+	task := new(models.Task)
+
+	res := aR.taskCollection.FindOne(ctx, bson.M{"_id": taskId})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoValidRecordFound
+	}
+
+	if err := res.Decode(task); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (aR AdminRepository) GetTasks(ctx context.Context, includeArchived bool) ([]models.Task, error) {
+	tasks := []models.Task{}
+
+	filter := bson.M{"archivedat": bson.M{"$exists": false}}
+	if includeArchived {
+		filter = bson.M{}
+	}
+
+	cursor, err := aR.taskCollection.Find(ctx, filter)
 	if err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
-	if err = cursor.All(ctx, students); err != nil {
+
+	if err = cursor.All(ctx, &tasks); err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
 
-	return *students, nil
+	return tasks, nil
 }
-func (aR AdminRepository) DeleteTask(ctx context.Context, taskId primitive.ObjectID) error {
-	res, err := aR.taskCollection.DeleteOne(ctx, bson.M{
-		"_id": taskId,
+
+// GetTasksDueForReminder returns the open tasks due in (from, to] that have not been reminded
+// about for their current due date. Tasks opening later are picked up by a run after they open.
+func (aR AdminRepository) GetTasksDueForReminder(ctx context.Context, from, to time.Time) ([]models.Task, error) {
+	tasks := []models.Task{}
+
+	cursor, err := aR.taskCollection.Find(ctx, bson.M{
+		"dueat": bson.M{
+			"$gt":  primitive.NewDateTimeFromTime(from),
+			"$lte": primitive.NewDateTimeFromTime(to),
+		},
+		"$or": bson.A{
+			bson.M{"openat": bson.M{"$exists": false}},
+			bson.M{"openat": bson.M{"$lte": primitive.NewDateTimeFromTime(from)}},
+		},
+		"$expr":      bson.M{"$ne": bson.A{"$reminderdueat", "$dueat"}},
+		"archivedat": bson.M{"$exists": false},
 	})
-	if res.DeletedCount == 0 {
-		aR.l.Println("No task found to be deleted")
-		return errors.New("no task found with given id")
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if err = cursor.All(ctx, &tasks); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return tasks, nil
+}
 
+// ClaimTaskReminder marks the task as reminded for its due date, it returns false when another
+// run already did so
+func (aR AdminRepository) ClaimTaskReminder(ctx context.Context, task models.Task) (bool, error) {
+	res, err := aR.taskCollection.UpdateOne(ctx, bson.M{
+		"_id":           task.Id,
+		"dueat":         task.DueAt,
+		"reminderdueat": bson.M{"$ne": task.DueAt},
+	}, bson.M{
+		"$set": bson.M{"reminderdueat": task.DueAt},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return false, err
 	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// ReleaseTaskReminder undoes ClaimTaskReminder so a later run reminds about the due date again
+func (aR AdminRepository) ReleaseTaskReminder(ctx context.Context, task models.Task) error {
+	_, err := aR.taskCollection.UpdateOne(ctx, bson.M{
+		"_id":           task.Id,
+		"reminderdueat": task.DueAt,
+	}, bson.M{
+		"$unset": bson.M{"reminderdueat": ""},
+	})
 	if err != nil {
 		aR.l.Println(err)
 		return err
 	}
+
 	return nil
 }
 
-func (aR AdminRepository) GetTaskSubmissions(c context.Context) ([]models.TaskSubmissionsAdminResponse, error) {
+// taskAudienceQuery matches the active students a task is for, see models.TaskAssignment
+func taskAudienceQuery(task models.Task) bson.M {
+	targets := bson.A{}
+
+	a := task.Assignment
+	if a.IsEmpty() || len(a.Colleges) > 0 || len(a.Courses) > 0 {
+		cohort := bson.M{
+			"domains":  task.Domain,
+			"semester": task.Semester,
+		}
+		if len(a.Colleges) > 0 {
+			cohort["college"] = bson.M{"$in": a.Colleges}
+		}
+		if len(a.Courses) > 0 {
+			cohort["course"] = bson.M{"$in": a.Courses}
+		}
+		targets = append(targets, cohort)
+	}
 
-	lookupStage1 := bson.D{{
-		"$lookup", bson.D{{
-			"from", "students",
-		}, {
-			"localField", "userid",
-		}, {
-			"foreignField", "_id",
-		}, {
-			"as", "student",
-		}},
+	if len(a.StudentIds) > 0 {
+		targets = append(targets, bson.M{"_id": bson.M{"$in": a.StudentIds}})
+	}
+
+	return bson.M{
+		"$or":    targets,
+		"status": bson.M{"$ne": models.STUDENT_DEACTIVATED},
+	}
+}
+
+// GetTaskAudience returns the students a task is for with the status of their submission
+func (aR AdminRepository) GetTaskAudience(ctx context.Context, task models.Task) ([]models.TaskAudienceMember, error) {
+	lookupStage := bson.D{{
+		Key: "$lookup", Value: bson.M{
+			"from": "task_submission",
+			"let":  bson.M{"uid": "$_id"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{
+					"taskid": task.Id,
+					"$expr":  bson.M{"$eq": bson.A{"$userid", "$$uid"}},
+				}}},
+				{{Key: "$project", Value: bson.M{"status": 1}}},
+			},
+			"as": "submission",
+		},
 	}}
 
-	unwindStage1 := bson.D{{
-		"$unwind", bson.D{{
-			"path", "$student",
-		}},
+	projectStage := bson.D{{
+		Key: "$project", Value: bson.M{
+			"email":     1,
+			"firstname": 1,
+			"lastname":  1,
+			"college":   1,
+			"course":    1,
+			"submissionstatus": bson.M{
+				"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$submission.status", 0}}, models.INACTIVE},
+			},
+		},
 	}}
 
-	projectStage1 := bson.D{{
+	cursor, err := aR.studentCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: taskAudienceQuery(task)}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		lookupStage,
+		projectStage,
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	audience := []models.TaskAudienceMember{}
+	if err = cursor.All(ctx, &audience); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return audience, nil
+}
+
+// GetPendingStudentsForTask returns the students in the task's audience who have not
+// submitted it
+func (aR AdminRepository) GetPendingStudentsForTask(ctx context.Context, task models.Task) ([]models.Student, error) {
+	students := []models.Student{}
+
+	submitted, err := aR.taskSubmissionCollection.Distinct(ctx, "userid", bson.M{"taskid": task.Id})
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	query := taskAudienceQuery(task)
+	query["_id"] = bson.M{"$nin": submitted}
+
+	cursor, err := aR.studentCollection.Find(ctx, query)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if err = cursor.All(ctx, &students); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return students, nil
+}
+
+func (aR AdminRepository) CreateDomain(c context.Context, domain models.StaticModel) error {
+	return insertStaticModelData(c, aR.domainCollection, domain)
+}
+
+func (aR AdminRepository) CreateNotification(ctx context.Context, notification models.NotificationEntity) error {
+	res, err := aR.notificationCollection.InsertOne(ctx, notification)
+	if err != nil {
+		return err
+	}
+	aR.l.Println("Inserted notification with id :> ", res.InsertedID)
+	return nil
+}
+
+func insertStaticModelData(c context.Context, collection *mongo.Collection, data models.StaticModel) error {
+	opts := options.Update().SetUpsert(true)
+
+	_, err := collection.UpdateOne(c, bson.M{
+		"name": data.Name,
+	}, bson.M{
+		"$set": data,
+	}, opts)
+
+	if err != nil {
+		return err
+	}
+	return nil
+
+}
+
+func findStaticModel(c context.Context, collection *mongo.Collection, name string) (*models.StaticModel, error) {
+	data := new(models.StaticModel)
+
+	res := collection.FindOne(c, bson.M{"name": name})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoValidRecordFound
+	}
+
+	if err := res.Decode(data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (aR AdminRepository) GetDomain(c context.Context, name string) (*models.StaticModel, error) {
+	return findStaticModel(c, aR.domainCollection, name)
+}
+
+func (aR AdminRepository) GetCollege(c context.Context, name string) (*models.StaticModel, error) {
+	return findStaticModel(c, aR.collegeCollection, name)
+}
+
+func (aR AdminRepository) GetCourse(c context.Context, name string) (*models.StaticModel, error) {
+	return findStaticModel(c, aR.courseCollection, name)
+}
+
+func (aR AdminRepository) staticCollection(kind models.StaticDataKind) *mongo.Collection {
+	switch kind {
+	case models.STATIC_COLLEGES:
+		return aR.collegeCollection
+	case models.STATIC_COURSES:
+		return aR.courseCollection
+	}
+	return aR.domainCollection
+}
+
+// staticReference is a field holding the name of a domain, college or course. update is the
+// path to set on rename, with arrayFilter matching the array elements holding the name.
+type staticReference struct {
+	name        string
+	collection  *mongo.Collection
+	field       string
+	update      string
+	arrayFilter string
+}
+
+// staticReferences lists the denormalized copies of a static name. Task version snapshots
+// keep the names they were saved with.
+func (aR AdminRepository) staticReferences(kind models.StaticDataKind) []staticReference {
+	switch kind {
+	case models.STATIC_COLLEGES:
+		return []staticReference{
+			{name: "students", collection: aR.studentCollection, field: "college", update: "college"},
+			{name: "tasks", collection: aR.taskCollection, field: "assignment.colleges", update: "assignment.colleges.$[ref]", arrayFilter: "ref"},
+		}
+	case models.STATIC_COURSES:
+		return []staticReference{
+			{name: "students", collection: aR.studentCollection, field: "course", update: "course"},
+			{name: "tasks", collection: aR.taskCollection, field: "assignment.courses", update: "assignment.courses.$[ref]", arrayFilter: "ref"},
+		}
+	}
+	return []staticReference{
+		{name: "students", collection: aR.studentCollection, field: "domains", update: "domains.$[ref]", arrayFilter: "ref"},
+		{name: "tasks", collection: aR.taskCollection, field: "domain", update: "domain"},
+		{name: "mentors", collection: aR.mentorCollection, field: "domain", update: "domain"},
+		{name: "mentor_videos", collection: aR.mentorCollection, field: "videos.domain", update: "videos.$[ref].domain", arrayFilter: "ref.domain"},
+	}
+}
+
+func (aR AdminRepository) GetStaticData(c context.Context, kind models.StaticDataKind) ([]models.StaticModel, error) {
+	data := []models.StaticModel{}
+
+	cursor, err := aR.staticCollection(kind).Find(c, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if err = cursor.All(c, &data); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (aR AdminRepository) FindStaticData(c context.Context, kind models.StaticDataKind, name string) (*models.StaticModel, error) {
+	return findStaticModel(c, aR.staticCollection(kind), name)
+}
+
+func (aR AdminRepository) RenameStaticData(c context.Context, kind models.StaticDataKind, from, to string) error {
+	res, err := aR.staticCollection(kind).UpdateOne(c, bson.M{"name": from}, bson.M{
+		"$set": bson.M{"name": to},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return models.ErrNoValidRecordFound
+	}
+
+	return nil
+}
+
+// PropagateStaticRename replaces from with to wherever the name is copied, returning the
+// documents updated per reference
+func (aR AdminRepository) PropagateStaticRename(c context.Context, kind models.StaticDataKind, from, to string) (map[string]int64, error) {
+	updated := map[string]int64{}
+
+	for _, ref := range aR.staticReferences(kind) {
+		opts := options.Update()
+		if ref.arrayFilter != "" {
+			opts.SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{ref.arrayFilter: from}}})
+		}
+
+		res, err := ref.collection.UpdateMany(c, bson.M{ref.field: from}, bson.M{
+			"$set": bson.M{ref.update: to},
+		}, opts)
+		if err != nil {
+			aR.l.Println(err)
+			return updated, err
+		}
+
+		updated[ref.name] += res.ModifiedCount
+	}
+
+	return updated, nil
+}
+
+// CountStaticReferences counts the documents per reference that hold the name
+func (aR AdminRepository) CountStaticReferences(c context.Context, kind models.StaticDataKind, name string) (map[string]int64, error) {
+	references := map[string]int64{}
+
+	for _, ref := range aR.staticReferences(kind) {
+		count, err := ref.collection.CountDocuments(c, bson.M{ref.field: name})
+		if err != nil {
+			aR.l.Println(err)
+			return nil, err
+		}
+
+		if count > 0 {
+			references[ref.name] = count
+		}
+	}
+
+	return references, nil
+}
+
+func (aR AdminRepository) DeleteStaticData(c context.Context, kind models.StaticDataKind, name string) error {
+	res, err := aR.staticCollection(kind).DeleteOne(c, bson.M{"name": name})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return models.ErrNoValidRecordFound
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) CreateCollege(c context.Context, college models.StaticModel) error {
+	return insertStaticModelData(c, aR.collegeCollection, college)
+}
+func (aR AdminRepository) CreateCourse(c context.Context, course models.StaticModel) error {
+	return insertStaticModelData(c, aR.courseCollection, course)
+}
+
+func studentFilterQuery(filter models.StudentFilter) bson.M {
+	query := bson.M{}
+
+	fields := map[string]string{
+		"college":  filter.College,
+		"course":   filter.Course,
+		"semester": filter.Semester,
+		"domains":  filter.Domain,
+		"district": filter.District,
+		"state":    filter.State,
+	}
+	for field, value := range fields {
+		if value != "" {
+			query[field] = value
+		}
+	}
+
+	if filter.HasArrears != nil {
+		query["hasarrears"] = *filter.HasArrears
+	}
+
+	switch filter.Status {
+	case "all":
+	case string(models.STUDENT_DEACTIVATED):
+		query["status"] = models.STUDENT_DEACTIVATED
+	default:
+		query["status"] = bson.M{"$ne": models.STUDENT_DEACTIVATED}
+	}
+
+	createdAt := bson.M{}
+	if !filter.CreatedFrom.IsZero() {
+		createdAt["$gte"] = primitive.NewDateTimeFromTime(filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		createdAt["$lte"] = primitive.NewDateTimeFromTime(filter.CreatedTo)
+	}
+	if len(createdAt) > 0 {
+		query["createdat"] = createdAt
+	}
+
+	return query
+}
+
+func (aR AdminRepository) GetUsers(ctx context.Context, filter models.StudentFilter) (models.Students, int64, error) {
+	students := new([]models.Student)
+
+	query := studentFilterQuery(filter)
+
+	total, err := aR.studentCollection.CountDocuments(ctx, query)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(studentSort(filter)).
+		SetSkip(filter.Skip()).
+		SetLimit(filter.Limit)
+
+	cursor, err := aR.studentCollection.Find(ctx, query, opts)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+	if err = cursor.All(ctx, students); err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	return *students, total, nil
+}
+
+// StreamUsers calls fn for every student matching the filter as it is read from the cursor,
+// pagination is ignored
+func (aR AdminRepository) StreamUsers(ctx context.Context, filter models.StudentFilter, fn func(models.Student) error) error {
+	opts := options.Find().SetSort(studentSort(filter))
+
+	cursor, err := aR.studentCollection.Find(ctx, studentFilterQuery(filter), opts)
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		student := models.Student{}
+		if err := cursor.Decode(&student); err != nil {
+			aR.l.Println(err)
+			return err
+		}
+		if err := fn(student); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// studentSort breaks ties on _id so pages are stable
+func studentSort(filter models.StudentFilter) bson.D {
+	sortField, sortOrder := filter.SortField()
+	sort := bson.D{{Key: sortField, Value: sortOrder}}
+	if sortField != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+
+	return sort
+}
+
+func (aR AdminRepository) findStudent(ctx context.Context, filter bson.M) (*models.Student, error) {
+	student := new(models.Student)
+
+	res := aR.studentCollection.FindOne(ctx, filter)
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoStudentWithIdExists
+	}
+
+	if err := res.Decode(student); err != nil {
+		aR.l.Println(err)
+		return nil, models.ErrParsingStudent
+	}
+
+	return student, nil
+}
+
+func (aR AdminRepository) CreateStudent(ctx context.Context, student models.Student) error {
+	_, err := aR.studentCollection.InsertOne(ctx, student)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return models.ErrStudentExists
+	}
+
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) GetStudentById(ctx context.Context, id primitive.ObjectID) (*models.Student, error) {
+	return aR.findStudent(ctx, bson.M{"_id": id})
+}
+
+func (aR AdminRepository) GetStudentByEmail(ctx context.Context, email string) (*models.Student, error) {
+	return aR.findStudent(ctx, bson.M{"email": email})
+}
+
+// UpdateStudent overwrites the profile fields, the password, mentors and status are left as they are
+func (aR AdminRepository) UpdateStudent(ctx context.Context, student models.Student) error {
+	res, err := aR.studentCollection.UpdateByID(ctx, student.ID, bson.M{
+		"$set": bson.M{
+			"email":            student.Email,
+			"firstname":        student.FirstName,
+			"domains":          student.Domains,
+			"lastname":         student.LastName,
+			"middlename":       student.MiddleName,
+			"dob":              student.DOB,
+			"gender":           student.Gender,
+			"phonenumber":      student.PhoneNumber,
+			"phonenumberalt":   student.PhoneNumberAlt,
+			"college":          student.College,
+			"course":           student.Course,
+			"specialization":   student.Specialization,
+			"hasarrears":       student.HasArrears,
+			"place":            student.Place,
+			"semester":         student.Semester,
+			"district":         student.District,
+			"state":            student.State,
+			"country":          student.Country,
+			"dateofjoining":    student.DateOfJoining,
+			"courseendingdate": student.CourseEndingDate,
+			"updatedat":        student.UpdatedAt,
+		},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return models.ErrNoStudentWithIdExists
+	}
+
+	return nil
+}
+
+// DeactivateStudent soft-deletes a student, the document stays so submissions and notifications still resolve it.
+// The student keeps their mentors but no longer holds a seat with them.
+func (aR AdminRepository) DeactivateStudent(ctx context.Context, id primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	res := aR.studentCollection.FindOneAndUpdate(ctx, bson.M{
+		"_id":    id,
+		"status": bson.M{"$ne": models.STUDENT_DEACTIVATED},
+	}, bson.M{
+		"$set": bson.M{
+			"status":        models.STUDENT_DEACTIVATED,
+			"deactivatedat": now,
+			"updatedat":     now,
+		},
+	})
+
+	if res.Err() == mongo.ErrNoDocuments {
+		// already deactivated, its seats were released then
+		_, err := aR.GetStudentById(ctx, id)
+		return err
+	}
+
+	student := models.Student{}
+	if err := res.Decode(&student); err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if len(student.Mentors) == 0 {
+		return nil
+	}
+
+	if _, err := aR.mentorCollection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": student.Mentors}}, bson.M{
+		"$inc": bson.M{"assigned": -1},
+	}); err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) DeleteTask(ctx context.Context, taskId primitive.ObjectID) error {
+	res, err := aR.taskCollection.DeleteOne(ctx, bson.M{
+		"_id": taskId,
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		aR.l.Println("No task found to be deleted")
+		return errors.New("no task found with given id")
+
+	}
+	return nil
+}
+
+// ArchiveTask sets archivedat on a task that is not archived yet
+func (aR AdminRepository) ArchiveTask(ctx context.Context, taskId primitive.ObjectID, at primitive.DateTime) error {
+	res, err := aR.taskCollection.UpdateOne(ctx, bson.M{
+		"_id":        taskId,
+		"archivedat": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"archivedat": at},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetTaskById(ctx, taskId); err != nil {
+			return err
+		}
+		return models.ErrTaskArchived
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) RestoreTask(ctx context.Context, taskId primitive.ObjectID) error {
+	res, err := aR.taskCollection.UpdateOne(ctx, bson.M{
+		"_id":        taskId,
+		"archivedat": bson.M{"$exists": true},
+	}, bson.M{
+		"$unset": bson.M{"archivedat": ""},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetTaskById(ctx, taskId); err != nil {
+			return err
+		}
+		return models.ErrTaskNotArchived
+	}
+
+	return nil
+}
+
+// CountTaskSubmissions counts the submissions of a task that are not archived
+func (aR AdminRepository) CountTaskSubmissions(ctx context.Context, taskId primitive.ObjectID) (int64, error) {
+	count, err := aR.taskSubmissionCollection.CountDocuments(ctx, bson.M{
+		"taskid":     taskId,
+		"archivedat": bson.M{"$exists": false},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ArchiveTaskSubmissions archives the task's submissions with the task's archivedat, so a
+// restore brings back exactly these
+func (aR AdminRepository) ArchiveTaskSubmissions(ctx context.Context, taskId primitive.ObjectID, at primitive.DateTime) (int64, error) {
+	res, err := aR.taskSubmissionCollection.UpdateMany(ctx, bson.M{
+		"taskid":     taskId,
+		"archivedat": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"archivedat": at},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// DeleteTaskSubmissions deletes every submission of the task, archived ones included
+func (aR AdminRepository) DeleteTaskSubmissions(ctx context.Context, taskId primitive.ObjectID) (int64, error) {
+	res, err := aR.taskSubmissionCollection.DeleteMany(ctx, bson.M{"taskid": taskId})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+func (aR AdminRepository) RestoreTaskSubmissions(ctx context.Context, taskId primitive.ObjectID, at primitive.DateTime) (int64, error) {
+	res, err := aR.taskSubmissionCollection.UpdateMany(ctx, bson.M{
+		"taskid":     taskId,
+		"archivedat": at,
+	}, bson.M{
+		"$unset": bson.M{"archivedat": ""},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func (aR AdminRepository) GetTaskSubmissions(c context.Context, filter models.SubmissionFilter) ([]models.TaskSubmissionsAdminResponse, int64, error) {
+	filterStages, studentJoined, err := aR.submissionFilterStages(c, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the page and the total come from a single pass over the matched submissions, only the
+	// page is joined with its task
+	facetStage := bson.D{{
+		Key: "$facet", Value: bson.M{
+			"data": append(mongo.Pipeline{
+				{{Key: "$skip", Value: filter.Skip()}},
+				{{Key: "$limit", Value: filter.Limit}},
+			}, taskSubmissionsPipeline(!studentJoined)...),
+			"total": mongo.Pipeline{
+				{{Key: "$count", Value: "count"}},
+			},
+		},
+	}}
+
+	cursor, err := aR.taskSubmissionCollection.Aggregate(c, append(filterStages, facetStage))
+	if err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	var facets []struct {
+		Data  []models.TaskSubmissionsAdminResponse `bson:"data"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err = cursor.All(c, &facets); err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	responseData := []models.TaskSubmissionsAdminResponse{}
+	var total int64
+	if len(facets) > 0 {
+		responseData = append(responseData, facets[0].Data...)
+		if len(facets[0].Total) > 0 {
+			total = facets[0].Total[0].Count
+		}
+	}
+
+	return responseData, total, nil
+}
+
+// StreamTaskSubmissions calls fn for every submission matching the filter as it is read from
+// the cursor, pagination is ignored
+func (aR AdminRepository) StreamTaskSubmissions(c context.Context, filter models.SubmissionFilter, fn func(models.TaskSubmissionsAdminResponse) error) error {
+	filterStages, studentJoined, err := aR.submissionFilterStages(c, filter)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := aR.taskSubmissionCollection.Aggregate(c, append(filterStages, taskSubmissionsPipeline(!studentJoined)...))
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		submission := models.TaskSubmissionsAdminResponse{}
+		if err := cursor.Decode(&submission); err != nil {
+			aR.l.Println(err)
+			return err
+		}
+		if err := fn(submission); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// submissionFilterStages matches and sorts submissions before any join. Domain and semester
+// live on the task, so the matching task ids are looked up first and matched on taskid. Only
+// the college filter needs the student joined, which is reported so it is not joined twice.
+func (aR AdminRepository) submissionFilterStages(c context.Context, filter models.SubmissionFilter) (mongo.Pipeline, bool, error) {
+	query := bson.M{}
+
+	if !filter.IncludeArchived {
+		query["archivedat"] = bson.M{"$exists": false}
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	if filter.TaskId != "" {
+		taskId, err := primitive.ObjectIDFromHex(filter.TaskId)
+		if err != nil {
+			return nil, false, models.ErrInvalidTaskId
+		}
+		query["taskid"] = taskId
+	}
+
+	if filter.Domain != "" || filter.Semester != "" {
+		taskQuery := bson.M{}
+		if filter.Domain != "" {
+			taskQuery["domain"] = filter.Domain
+		}
+		if filter.Semester != "" {
+			taskQuery["semester"] = filter.Semester
+		}
+
+		taskIds, err := aR.taskCollection.Distinct(c, "_id", taskQuery)
+		if err != nil {
+			aR.l.Println(err)
+			return nil, false, err
+		}
+
+		if taskId, ok := query["taskid"]; ok {
+			query["$and"] = bson.A{
+				bson.M{"taskid": taskId},
+				bson.M{"taskid": bson.M{"$in": taskIds}},
+			}
+			delete(query, "taskid")
+		} else {
+			query["taskid"] = bson.M{"$in": taskIds}
+		}
+	}
+
+	updatedAt := bson.M{}
+	if !filter.From.IsZero() {
+		updatedAt["$gte"] = primitive.NewDateTimeFromTime(filter.From)
+	}
+	if !filter.To.IsZero() {
+		updatedAt["$lte"] = primitive.NewDateTimeFromTime(filter.To)
+	}
+	if len(updatedAt) > 0 {
+		query["updatedat"] = updatedAt
+	}
+
+	stages := mongo.Pipeline{{{Key: "$match", Value: query}}}
+
+	studentJoined := filter.College != ""
+	if studentJoined {
+		stages = append(stages, submissionStudentStages()...)
+		stages = append(stages, bson.D{{Key: "$match", Value: bson.M{"student.college": filter.College}}})
+	}
+
+	sortField, sortOrder := filter.SortField()
+	sort := bson.D{{Key: sortField, Value: sortOrder}}
+	if sortField != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+	stages = append(stages, bson.D{{Key: "$sort", Value: sort}})
+
+	return stages, studentJoined, nil
+}
+
+// submissionStudentStages joins a submission with its student
+func submissionStudentStages() mongo.Pipeline {
+	lookupStage1 := bson.D{{
+		"$lookup", bson.D{{
+			"from", "students",
+		}, {
+			"localField", "userid",
+		}, {
+			"foreignField", "_id",
+		}, {
+			"as", "student",
+		}},
+	}}
+
+	unwindStage1 := bson.D{{
+		"$unwind", bson.D{{
+			"path", "$student",
+		}},
+	}}
+
+	return mongo.Pipeline{lookupStage1, unwindStage1}
+}
+
+// taskSubmissionsPipeline joins submissions with their task, and with their student unless an
+// earlier stage already did
+func taskSubmissionsPipeline(joinStudent bool) mongo.Pipeline {
+	stages := mongo.Pipeline{}
+	if joinStudent {
+		stages = append(stages, submissionStudentStages()...)
+	}
+
+	projectStage1 := bson.D{{
 		"$project", bson.D{
 			{
 				"student.email", 1,
@@ -250,215 +1388,790 @@ func (aR AdminRepository) GetTaskSubmissions(c context.Context) ([]models.TaskSu
 			{
 				"updatedat", 1,
 			},
+			{
+				Key: "createdat", Value: 1,
+			},
+			{
+				Key: "reviews", Value: 1,
+			},
+			{
+				Key: "taskversion", Value: 1,
+			},
+		},
+	}}
+
+	lookupStage2 := bson.D{{
+		"$lookup", bson.D{{
+			"from", "tasks",
+		},
+			{
+				"localField", "taskid",
+			},
+			{
+				"foreignField", "_id",
+			},
+			{
+				"as", "task",
+			},
+		},
+	}}
+
+	// submissions of a deleted task are kept with a null task
+	unwindStage2 := bson.D{{
+		"$unwind", bson.D{{
+			"path", "$task",
+		}, {
+			Key: "preserveNullAndEmptyArrays", Value: true,
+		}},
+	}}
+
+	projectStage2 := bson.D{{
+		"$project", bson.D{{
+			"taskid", 0,
+		}, {
+			Key: "versionatsubmission", Value: 0,
+		}},
+	}}
+
+	// a submission is late when it was first submitted after the task's due date, older
+	// submissions without createdat fall back to their last update
+	timelinessStage := bson.D{{
+		Key: "$addFields", Value: bson.M{
+			"timeliness": bson.M{
+				"$cond": bson.A{
+					bson.M{"$not": bson.A{"$task.dueat"}},
+					"$$REMOVE",
+					bson.M{"$cond": bson.A{
+						bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$createdat", "$updatedat"}}, "$task.dueat"}},
+						models.LATE,
+						models.ON_TIME,
+					}},
+				},
+			},
 		},
 	}}
 
-	lookupStage2 := bson.D{{
-		"$lookup", bson.D{{
-			"from", "tasks",
-		},
-			{
-				"localField", "taskid",
+	// the version in effect when the submission was made, unless the submission recorded it
+	versionLookupStage := bson.D{{
+		Key: "$lookup", Value: bson.M{
+			"from": "task_versions",
+			"let": bson.M{
+				"tid": "$task._id",
+				"at":  bson.M{"$ifNull": bson.A{"$createdat", "$updatedat"}},
 			},
-			{
-				"foreignField", "_id",
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$task_id", "$$tid"}},
+					bson.M{"$lte": bson.A{"$created_at", "$$at"}},
+				}}}}},
+				{{Key: "$sort", Value: bson.M{"version": -1}}},
+				{{Key: "$limit", Value: 1}},
+				{{Key: "$project", Value: bson.M{"version": 1}}},
 			},
-			{
-				"as", "task",
+			"as": "versionatsubmission",
+		},
+	}}
+
+	versionStage := bson.D{{
+		Key: "$addFields", Value: bson.M{
+			"taskversion": bson.M{
+				"$ifNull": bson.A{"$taskversion", bson.M{"$arrayElemAt": bson.A{"$versionatsubmission.version", 0}}},
 			},
 		},
 	}}
 
-	unwindStage2 := bson.D{{
-		"$unwind", bson.D{{
-			"path", "$task",
-		}},
-	}}
+	return append(stages, projectStage1, lookupStage2, unwindStage2, timelinessStage, versionLookupStage, versionStage, projectStage2)
+}
+
+func (aR AdminRepository) GetTaskSubmissionById(c context.Context, id primitive.ObjectID) (*models.TaskSubmission, error) {
+	submission := new(models.TaskSubmission)
+
+	res := aR.taskSubmissionCollection.FindOne(c, bson.M{"_id": id})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoValidRecordFound
+	}
+
+	if err := res.Decode(submission); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// ReviewTaskSubmission sets the new status and appends the review to the submission's timeline.
+// The update only applies while the submission still has the review's old status.
+func (aR AdminRepository) ReviewTaskSubmission(c context.Context, id primitive.ObjectID, review models.SubmissionReview) error {
+	res, err := aR.taskSubmissionCollection.UpdateOne(c, bson.M{
+		"_id":    id,
+		"status": review.OldStatus,
+	}, bson.M{
+		"$set": bson.M{
+			"status":    review.NewStatus,
+			"updatedat": review.CreatedAt,
+		},
+		"$push": bson.M{
+			"reviews": review,
+		},
+	})
+
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		aR.l.Println(models.ErrSubmissionModified)
+		return models.ErrSubmissionModified
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) GetTaskSubmissionsForUser(c context.Context, userid primitive.ObjectID) ([]models.TaskSubmissionsAdminResponse, error) {
+	filter := bson.D{{
+		"$match", bson.D{{
+			"userid", userid,
+		}},
+	}}
+
+	cursor, err := aR.taskSubmissionCollection.Aggregate(c, append(mongo.Pipeline{filter}, taskSubmissionsPipeline(true)...))
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	var responseData []models.TaskSubmissionsAdminResponse
+	if err = cursor.All(c, &responseData); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if len(responseData) == 0 {
+		return responseData, fmt.Errorf("no data exists for user id")
+	}
+
+	return responseData, nil
+
+}
+
+func (aR AdminRepository) CreateMentor(c context.Context, mentor models.Mentor) error {
+	res, err := aR.mentorCollection.InsertOne(c, mentor)
+
+	if mongo.IsDuplicateKeyError(err) {
+		aR.l.Println("mentor already exists")
+		return models.ErrMentorExists
+	}
+
+	if err != nil {
+		aR.l.Println("Failed to create mentor")
+		return err
+	}
+
+	aR.l.Println("Inserted mentor with ID", res.InsertedID)
+
+	return nil
+}
+
+func (aR AdminRepository) UpdateMentor(c context.Context, mentor models.Mentor) error {
+	opts := options.Update().SetUpsert(true)
+
+	up, err := utils.ToDoc(mentor)
+
+	if err != nil {
+		return err
+	}
+
+	doc := bson.M{"$set": up}
+
+	res, err := aR.mentorCollection.UpdateByID(c, mentor.ID, doc, opts)
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+	aR.l.Println(res.MatchedCount)
+	return nil
+}
+
+// mentorSelectionQuery matches the active students picked by an assignment request
+func mentorSelectionQuery(selection models.MentorAssignmentDTO) bson.M {
+	query := bson.M{"status": bson.M{"$ne": models.STUDENT_DEACTIVATED}}
+
+	if len(selection.StudentIds) > 0 {
+		query["_id"] = bson.M{"$in": selection.StudentIds}
+		return query
+	}
+
+	if selection.Domain != "" {
+		query["domains"] = selection.Domain
+	}
+	if selection.College != "" {
+		query["college"] = selection.College
+	}
+
+	return query
+}
+
+// CountMentorStudents counts the active students assigned to the mentor
+func (aR AdminRepository) CountMentorStudents(c context.Context, mentorId primitive.ObjectID) (int64, error) {
+	count, err := aR.studentCollection.CountDocuments(c, bson.M{
+		"mentors": mentorId,
+		"status":  bson.M{"$ne": models.STUDENT_DEACTIVATED},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetUnassignedStudentIds returns the ids of the selected students not yet assigned to the mentor
+func (aR AdminRepository) GetUnassignedStudentIds(c context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) ([]primitive.ObjectID, error) {
+	query := mentorSelectionQuery(selection)
+	query["mentors"] = bson.M{"$ne": mentorId}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := aR.studentCollection.Find(c, query, opts)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	students := []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}{}
+	if err = cursor.All(c, &students); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(students))
+	for i, student := range students {
+		ids[i] = student.ID
+	}
+
+	return ids, nil
+}
+
+// ReserveMentorSeats adds n to the mentor's assigned count if that keeps it within capacity,
+// it returns false when it would not. Mentors without a capacity always have room.
+func (aR AdminRepository) ReserveMentorSeats(c context.Context, mentorId primitive.ObjectID, n int64) (bool, error) {
+	res, err := aR.mentorCollection.UpdateOne(c, bson.M{
+		"_id": mentorId,
+		"$expr": bson.M{"$or": bson.A{
+			bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$capacity", 0}}, 0}},
+			bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$assigned", 0}}, n}}, "$capacity"}},
+		}},
+	}, bson.M{
+		"$inc": bson.M{"assigned": n},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return false, err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetMentorById(c, mentorId); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// AdjustMentorAssigned adds delta to the mentor's assigned count, it releases seats with a
+// negative delta
+func (aR AdminRepository) AdjustMentorAssigned(c context.Context, mentorId primitive.ObjectID, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+
+	if _, err := aR.mentorCollection.UpdateByID(c, mentorId, bson.M{"$inc": bson.M{"assigned": delta}}); err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// BackfillMentorAssigned counts the students of mentors stored before the assigned count existed
+func (aR AdminRepository) BackfillMentorAssigned(c context.Context) error {
+	cursor, err := aR.mentorCollection.Find(c, bson.M{"assigned": bson.M{"$exists": false}})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		mentor := models.Mentor{}
+		if err := cursor.Decode(&mentor); err != nil {
+			aR.l.Println(err)
+			return err
+		}
+
+		count, err := aR.CountMentorStudents(c, mentor.ID)
+		if err != nil {
+			return err
+		}
+
+		// a reservation made meanwhile already created the field, leave it be
+		if _, err := aR.mentorCollection.UpdateOne(c, bson.M{
+			"_id":      mentor.ID,
+			"assigned": bson.M{"$exists": false},
+		}, bson.M{
+			"$set": bson.M{"assigned": count},
+		}); err != nil {
+			aR.l.Println(err)
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// AssignMentor assigns the mentor to the given students, those deactivated or already assigned
+// meanwhile are skipped
+func (aR AdminRepository) AssignMentor(c context.Context, mentorId primitive.ObjectID, studentIds []primitive.ObjectID) (int64, error) {
+	res, err := aR.studentCollection.UpdateMany(c, bson.M{
+		"_id":     bson.M{"$in": studentIds},
+		"mentors": bson.M{"$ne": mentorId},
+		"status":  bson.M{"$ne": models.STUDENT_DEACTIVATED},
+	}, bson.M{
+		"$addToSet": bson.M{"mentors": mentorId},
+		"$set":      bson.M{"updatedat": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// UnassignMentor removes the mentor from the selected students, deactivated students included.
+// It returns how many students were changed and how many of them were active, only those held
+// a seat.
+func (aR AdminRepository) UnassignMentor(c context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (int64, int64, error) {
+	update := bson.M{
+		"$pull": bson.M{"mentors": mentorId},
+		"$set":  bson.M{"updatedat": primitive.NewDateTimeFromTime(time.Now())},
+	}
+
+	query := mentorSelectionQuery(selection)
+	query["mentors"] = mentorId
+
+	active, err := aR.studentCollection.UpdateMany(c, query, update)
+	if err != nil {
+		aR.l.Println(err)
+		return 0, 0, err
+	}
+
+	query["status"] = models.STUDENT_DEACTIVATED
+
+	deactivated, err := aR.studentCollection.UpdateMany(c, query, update)
+	if err != nil {
+		aR.l.Println(err)
+		return active.ModifiedCount, active.ModifiedCount, err
+	}
+
+	return active.ModifiedCount + deactivated.ModifiedCount, active.ModifiedCount, nil
+}
+
+// GetMentorStudents returns the active students assigned to the mentor
+func (aR AdminRepository) GetMentorStudents(c context.Context, mentorId primitive.ObjectID) (models.Students, error) {
+	students := models.Students{}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+
+	cursor, err := aR.studentCollection.Find(c, bson.M{
+		"mentors": mentorId,
+		"status":  bson.M{"$ne": models.STUDENT_DEACTIVATED},
+	}, opts)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	if err = cursor.All(c, &students); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return students, nil
+}
+
+func (aR AdminRepository) ArchiveMentor(c context.Context, mentorId primitive.ObjectID, at primitive.DateTime) error {
+	res, err := aR.mentorCollection.UpdateOne(c, bson.M{
+		"_id":        mentorId,
+		"archivedat": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"archivedat": at, "updatedat": at},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetMentorById(c, mentorId); err != nil {
+			return err
+		}
+		return models.ErrMentorArchived
+	}
+
+	return nil
+}
+
+// DetachMentor removes the mentor from every student, deactivated students included
+func (aR AdminRepository) DetachMentor(c context.Context, mentorId primitive.ObjectID) (int64, error) {
+	res, err := aR.studentCollection.UpdateMany(c, bson.M{"mentors": mentorId}, bson.M{
+		"$pull": bson.M{"mentors": mentorId},
+		"$set":  bson.M{"updatedat": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	if _, err := aR.mentorCollection.UpdateByID(c, mentorId, bson.M{"$set": bson.M{"assigned": 0}}); err != nil {
+		aR.l.Println(err)
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func (aR AdminRepository) AddMentorVideo(c context.Context, mentorId primitive.ObjectID, video models.Videos) error {
+	res, err := aR.mentorCollection.UpdateOne(c, bson.M{"_id": mentorId}, bson.M{
+		"$push": bson.M{"videos": video},
+		"$set":  bson.M{"updatedat": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return models.ErrNoValidRecordFound
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) RemoveMentorVideo(c context.Context, mentorId, videoId primitive.ObjectID) error {
+	res, err := aR.mentorCollection.UpdateOne(c, bson.M{"_id": mentorId, "videos._id": videoId}, bson.M{
+		"$pull": bson.M{"videos": bson.M{"_id": videoId}},
+		"$set":  bson.M{"updatedat": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetMentorById(c, mentorId); err != nil {
+			return err
+		}
+		return models.ErrVideoNotFound
+	}
+
+	return nil
+}
+
+// SetMentorVideos replaces the videos of a mentor, provided the mentor still has exactly the
+// videos in expected
+func (aR AdminRepository) SetMentorVideos(c context.Context, mentorId primitive.ObjectID, expected []primitive.ObjectID, videos []models.Videos) error {
+	res, err := aR.mentorCollection.UpdateOne(c, bson.M{
+		"_id":        mentorId,
+		"videos":     bson.M{"$size": len(expected)},
+		"videos._id": bson.M{"$all": expected},
+	}, bson.M{
+		"$set": bson.M{"videos": videos, "updatedat": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetMentorById(c, mentorId); err != nil {
+			return err
+		}
+		return models.ErrMentorModified
+	}
 
-	projectStage2 := bson.D{{
-		"$project", bson.D{{
-			"taskid", 0,
-		}},
-	}}
+	return nil
+}
+
+// GetVideos lists the videos of active mentors in mentor order, optionally for one domain only
+func (aR AdminRepository) GetVideos(c context.Context, domain string) ([]models.MentorVideo, error) {
+	videos := []models.MentorVideo{}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"archivedat": bson.M{"$exists": false}}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$videos", "includeArrayIndex": "position"}}},
+	}
+
+	if domain != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"videos.domain": domain}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}, {Key: "position", Value: 1}}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+			"$videos",
+			bson.M{"mentor_id": "$_id", "mentor_name": "$name"},
+		}}}}},
+	)
 
-	cursor, err := aR.taskSubmissionCollection.Aggregate(c, mongo.Pipeline{lookupStage1, unwindStage1, projectStage1, lookupStage2, unwindStage2, projectStage2})
+	cursor, err := aR.mentorCollection.Aggregate(c, pipeline)
 	if err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
-	var responseData []models.TaskSubmissionsAdminResponse
-	if err = cursor.All(c, &responseData); err != nil {
+
+	if err = cursor.All(c, &videos); err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
 
-	return responseData, nil
+	return videos, nil
 }
 
-func (aR AdminRepository) EditTaskSubmissionStatus(c context.Context, status models.Status, taskid primitive.ObjectID) error {
+// BackfillMentorVideos gives videos stored before they had ids an id, and the mentor's domain
+func (aR AdminRepository) BackfillMentorVideos(c context.Context) error {
+	cursor, err := aR.mentorCollection.Find(c, bson.M{
+		"videos": bson.M{"$elemMatch": bson.M{"_id": bson.M{"$exists": false}}},
+	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		mentor := models.Mentor{}
+		if err := cursor.Decode(&mentor); err != nil {
+			aR.l.Println(err)
+			return err
+		}
+
+		for i := range mentor.Videos {
+			if mentor.Videos[i].Id.IsZero() {
+				mentor.Videos[i].Id = primitive.NewObjectID()
+			}
+			if mentor.Videos[i].Domain == "" {
+				mentor.Videos[i].Domain = mentor.Domain
+			}
+		}
+
+		if _, err := aR.mentorCollection.UpdateByID(c, mentor.ID, bson.M{
+			"$set": bson.M{"videos": mentor.Videos},
+		}); err != nil {
+			aR.l.Println(err)
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
 
-	res, err := aR.taskSubmissionCollection.UpdateByID(c, taskid, bson.M{
-		"$set": bson.M{
-			"status":    status,
-			"updatedat": time.Now(),
-		},
+func (aR AdminRepository) SetMentorAvailability(c context.Context, mentorId primitive.ObjectID, slots []models.AvailabilitySlot) error {
+	res, err := aR.mentorCollection.UpdateOne(c, bson.M{"_id": mentorId}, bson.M{
+		"$set": bson.M{"availability": slots, "updatedat": primitive.NewDateTimeFromTime(time.Now())},
 	})
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
 
 	if res.MatchedCount == 0 {
-		aR.l.Println(models.ErrNoValidRecordFound)
 		return models.ErrNoValidRecordFound
 	}
 
-	if err != nil {
+	return nil
+}
+
+func (aR AdminRepository) CreateSession(c context.Context, session models.MentorSession) error {
+	if _, err := aR.sessionCollection.InsertOne(c, session); err != nil {
 		aR.l.Println(err)
 		return err
 	}
+
 	return nil
 }
 
-func (aR AdminRepository) GetTaskSubmissionsForUser(c context.Context, userid primitive.ObjectID) ([]models.TaskSubmissionsAdminResponse, error) {
-	filter := bson.D{{
-		"$match", bson.D{{
-			"userid", userid,
-		}},
-	}}
-	lookupStage1 := bson.D{{
-		"$lookup", bson.D{{
-			"from", "students",
-		}, {
-			"localField", "userid",
-		}, {
-			"foreignField", "_id",
-		}, {
-			"as", "student",
-		}},
-	}}
-
-	unwindStage1 := bson.D{{
-		"$unwind", bson.D{{
-			"path", "$student",
-		}},
-	}}
+func (aR AdminRepository) DeleteSession(c context.Context, sessionId primitive.ObjectID) error {
+	if _, err := aR.sessionCollection.DeleteOne(c, bson.M{"_id": sessionId}); err != nil {
+		aR.l.Println(err)
+		return err
+	}
 
-	projectStage1 := bson.D{{
-		"$project", bson.D{
-			{
-				"student.email", 1,
-			},
-			{
-				"student._id", 1,
-			},
-			{
-				"_id", 1,
-			},
-			{
-				"taskid", 1,
-			},
-			{
-				"comment", 1,
-			},
-			{
-				"fileurl", 1,
-			},
-			{
-				"status", 1,
-			},
-			{
-				"updatedat", 1,
-			},
-		},
-	}}
+	return nil
+}
 
-	lookupStage2 := bson.D{{
-		"$lookup", bson.D{{
-			"from", "tasks",
-		},
-			{
-				"localField", "taskid",
-			},
-			{
-				"foreignField", "_id",
-			},
-			{
-				"as", "task",
-			},
+// GetSessionConflicts returns the scheduled sessions of the mentor or the student that overlap
+// start to end, oldest booking first
+func (aR AdminRepository) GetSessionConflicts(c context.Context, session models.MentorSession) ([]models.MentorSession, error) {
+	sessions := []models.MentorSession{}
+
+	query := bson.M{
+		"_id":      bson.M{"$ne": session.ID},
+		"status":   models.SESSION_SCHEDULED,
+		"start_at": bson.M{"$lt": session.EndAt},
+		"end_at":   bson.M{"$gt": session.StartAt},
+		"$or": bson.A{
+			bson.M{"mentor_id": session.MentorId},
+			bson.M{"student_id": session.StudentId},
 		},
-	}}
-
-	unwindStage2 := bson.D{{
-		"$unwind", bson.D{{
-			"path", "$task",
-		}},
-	}}
-
-	projectStage2 := bson.D{{
-		"$project", bson.D{{
-			"taskid", 0,
-		}},
-	}}
+	}
 
-	cursor, err := aR.taskSubmissionCollection.Aggregate(c, mongo.Pipeline{filter, lookupStage1, unwindStage1, projectStage1, lookupStage2, unwindStage2, projectStage2})
+	cursor, err := aR.sessionCollection.Find(c, query, options.Find().SetSort(bson.M{"_id": 1}))
 	if err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
 
-	var responseData []models.TaskSubmissionsAdminResponse
-	if err = cursor.All(c, &responseData); err != nil {
+	if err = cursor.All(c, &sessions); err != nil {
 		aR.l.Println(err)
 		return nil, err
 	}
 
-	if len(responseData) == 0 {
-		return responseData, fmt.Errorf("no data exists for user id")
-	}
-
-	return responseData, nil
-
+	return sessions, nil
 }
 
-func (aR AdminRepository) CreateMentor(c context.Context, mentor models.Mentor) error {
-	res, err := aR.mentorCollection.InsertOne(c, mentor)
+func (aR AdminRepository) GetSessionById(c context.Context, sessionId primitive.ObjectID) (*models.MentorSession, error) {
+	session := new(models.MentorSession)
 
-	if mongo.IsDuplicateKeyError(err) {
-		aR.l.Println("mentor already exists")
-		return models.ErrMentorExists
+	res := aR.sessionCollection.FindOne(c, bson.M{"_id": sessionId})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoValidRecordFound
 	}
 
+	if err := res.Decode(session); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (aR AdminRepository) CancelSession(c context.Context, sessionId primitive.ObjectID, reason string) error {
+	res, err := aR.sessionCollection.UpdateOne(c, bson.M{
+		"_id":    sessionId,
+		"status": models.SESSION_SCHEDULED,
+	}, bson.M{
+		"$set": bson.M{
+			"status":        models.SESSION_CANCELLED,
+			"cancel_reason": reason,
+			"cancelled_at":  primitive.NewDateTimeFromTime(time.Now()),
+		},
+	})
 	if err != nil {
-		aR.l.Println("Failed to create mentor")
+		aR.l.Println(err)
 		return err
 	}
 
-	aR.l.Println("Inserted mentor with ID", res.InsertedID)
+	if res.MatchedCount == 0 {
+		if _, err := aR.GetSessionById(c, sessionId); err != nil {
+			return err
+		}
+		return models.ErrSessionNotScheduled
+	}
 
 	return nil
 }
 
-func (aR AdminRepository) UpdateMentor(c context.Context, mentor models.Mentor) error {
-	opts := options.Update().SetUpsert(true)
+func (aR AdminRepository) GetSessions(c context.Context, filter models.SessionFilter) ([]models.MentorSession, int64, error) {
+	sessions := []models.MentorSession{}
 
-	up, err := utils.ToDoc(mentor)
+	query := bson.M{}
+
+	if filter.MentorId != "" {
+		mentorId, err := primitive.ObjectIDFromHex(filter.MentorId)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["mentor_id"] = mentorId
+	}
+
+	if filter.StudentId != "" {
+		studentId, err := primitive.ObjectIDFromHex(filter.StudentId)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["student_id"] = studentId
+	}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
 
+	startAt := bson.M{}
+	if !filter.From.IsZero() {
+		startAt["$gte"] = primitive.NewDateTimeFromTime(filter.From)
+	}
+	if !filter.To.IsZero() {
+		startAt["$lte"] = primitive.NewDateTimeFromTime(filter.To)
+	}
+	if len(startAt) > 0 {
+		query["start_at"] = startAt
+	}
+
+	total, err := aR.sessionCollection.CountDocuments(c, query)
 	if err != nil {
-		return err
+		aR.l.Println(err)
+		return nil, 0, err
 	}
 
-	doc := bson.M{"$set": up}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "start_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(filter.Skip()).
+		SetLimit(filter.Limit)
 
-	res, err := aR.mentorCollection.UpdateByID(c, mentor.ID, doc, opts)
+	cursor, err := aR.sessionCollection.Find(c, query, opts)
 	if err != nil {
 		aR.l.Println(err)
-		return err
+		return nil, 0, err
 	}
-	aR.l.Println(res.MatchedCount)
-	return nil
+
+	if err = cursor.All(c, &sessions); err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	return sessions, total, nil
+}
+
+func (aR AdminRepository) GetMentorById(c context.Context, id primitive.ObjectID) (*models.Mentor, error) {
+	mentor := new(models.Mentor)
+
+	res := aR.mentorCollection.FindOne(c, bson.M{"_id": id})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoValidRecordFound
+	}
+
+	if err := res.Decode(mentor); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+
+	return mentor, nil
 }
 
-func (aR AdminRepository) GetMentors(c context.Context) ([]models.Mentor, error) {
+func (aR AdminRepository) GetMentors(c context.Context, includeArchived bool) ([]models.Mentor, error) {
 	mentors := []models.Mentor{}
 
-	cursor, err := aR.mentorCollection.Find(c, bson.M{})
+	filter := bson.M{}
+	if !includeArchived {
+		filter["archivedat"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := aR.mentorCollection.Find(c, filter)
 
 	if err != nil {
 		aR.l.Println(err)
@@ -538,3 +2251,151 @@ func (aR AdminRepository) GetCourses(ctx context.Context) ([]models.StaticModel,
 
 	return c, nil
 }
+
+func (aR AdminRepository) CreateAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	_, err := aR.auditCollection.InsertOne(ctx, entry)
+	if err != nil {
+		aR.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (aR AdminRepository) GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, int64, error) {
+	entries := []models.AuditEntry{}
+
+	query := bson.M{}
+
+	if filter.ActorId != "" {
+		actorId, err := primitive.ObjectIDFromHex(filter.ActorId)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["actor_id"] = actorId
+	}
+
+	if filter.Collection != "" {
+		query["collection"] = filter.Collection
+	}
+
+	if filter.EntityId != "" {
+		query["entity_id"] = filter.EntityId
+	}
+
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = primitive.NewDateTimeFromTime(filter.From)
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lte"] = primitive.NewDateTimeFromTime(filter.To)
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	total, err := aR.auditCollection.CountDocuments(ctx, query)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(filter.Skip()).
+		SetLimit(filter.Limit)
+
+	cursor, err := aR.auditCollection.Find(ctx, query, opts)
+	if err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	if err = cursor.All(ctx, &entries); err != nil {
+		aR.l.Println(err)
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func textSearch(ctx context.Context, collection *mongo.Collection, query string, filter bson.M, limit int64, results interface{}) error {
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(limit)
+
+	match := bson.M{"$text": bson.M{"$search": query}}
+	for k, v := range filter {
+		match[k] = v
+	}
+
+	cursor, err := collection.Find(ctx, match, opts)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, results)
+}
+
+// Search runs a text search over active students, unarchived tasks and unarchived mentors, every
+// collection returns at most limit results
+func (aR AdminRepository) Search(ctx context.Context, query string, limit int64) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+
+	students := []struct {
+		models.Student `bson:",inline"`
+		Score          float64 `bson:"score"`
+	}{}
+	if err := textSearch(ctx, aR.studentCollection, query, bson.M{"status": bson.M{"$ne": models.STUDENT_DEACTIVATED}}, limit, &students); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+	for _, s := range students {
+		results = append(results, models.SearchResult{
+			Type:     models.STUDENT_RESULT,
+			ID:       s.ID,
+			Title:    strings.TrimSpace(s.FirstName + " " + s.LastName),
+			Subtitle: s.Email + " " + s.PhoneNumber,
+			Score:    s.Score,
+		})
+	}
+
+	tasks := []struct {
+		models.Task `bson:",inline"`
+		Score       float64 `bson:"score"`
+	}{}
+	if err := textSearch(ctx, aR.taskCollection, query, bson.M{"archivedat": bson.M{"$exists": false}}, limit, &tasks); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+	for _, t := range tasks {
+		results = append(results, models.SearchResult{
+			Type:     models.TASK_RESULT,
+			ID:       t.Id,
+			Title:    t.Title,
+			Subtitle: t.Domain + " " + t.Semester,
+			Score:    t.Score,
+		})
+	}
+
+	mentors := []struct {
+		models.Mentor `bson:",inline"`
+		Score         float64 `bson:"score"`
+	}{}
+	if err := textSearch(ctx, aR.mentorCollection, query, bson.M{"archivedat": bson.M{"$exists": false}}, limit, &mentors); err != nil {
+		aR.l.Println(err)
+		return nil, err
+	}
+	for _, m := range mentors {
+		results = append(results, models.SearchResult{
+			Type:     models.MENTOR_RESULT,
+			ID:       m.ID,
+			Title:    m.Name,
+			Subtitle: m.Organization,
+			Score:    m.Score,
+		})
+	}
+
+	return results, nil
+}
@@ -4,27 +4,80 @@ import (
 	"context"
 
 	"github.com/asishshaji/admin-api/models"
+	"github.com/asishshaji/admin-api/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type IAdminService interface {
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password, ip string) (models.LoginResponse, error)
+	VerifyLoginTOTP(ctx context.Context, challengeToken, code, recoveryCode, ip string) (models.LoginResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+	Logout(ctx context.Context, accessClaims *models.AdminJWTClaims, refreshToken string) error
+	IsTokenRevoked(ctx context.Context, claims *models.AdminJWTClaims) (bool, error)
+	GetLoginLockouts(ctx context.Context) ([]models.LoginLockout, error)
+	ClearLoginLockout(ctx context.Context, scope models.LockoutScope, key string) error
+
+	ChangePassword(ctx context.Context, adminId primitive.ObjectID, currentPassword, newPassword string) error
+	CreatePasswordReset(ctx context.Context, actorId, adminId primitive.ObjectID) (models.PasswordResetResponse, error)
+	ResetPassword(ctx context.Context, token, newPassword string) error
+
+	BeginTOTPEnrolment(ctx context.Context, adminId primitive.ObjectID) (models.TOTPEnrolment, error)
+	ConfirmTOTPEnrolment(ctx context.Context, adminId primitive.ObjectID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, adminId primitive.ObjectID, password string) error
+	ResetAdminTOTP(ctx context.Context, actorId, adminId primitive.ObjectID) error
+
+	CreateAdmin(ctx context.Context, admin models.AdminDTO) error
+	GetAdmins(ctx context.Context) ([]models.AdminResponse, error)
+	SetAdminDisabled(ctx context.Context, actorId, adminId primitive.ObjectID, disabled bool) error
+	DeleteAdmin(ctx context.Context, actorId, adminId primitive.ObjectID) error
+
 	AddTask(ctx context.Context, task models.TaskDTO, creatorID primitive.ObjectID) error
 	UpdateTask(ctx context.Context, task models.TaskDTO) error
-	DeleteTask(c context.Context, taskId primitive.ObjectID) error
-	GetTasks(ctx context.Context) ([]models.Task, error)
-	GetUsers(ctx context.Context) ([]models.StudentResponse, error)
-	GetTaskSubmissions(c context.Context) ([]models.TaskSubmissionsAdminResponse, error)
-	EditTaskSubmission(ctx context.Context, uid primitive.ObjectID, taskId primitive.ObjectID, status models.Status) error
+	DeleteTask(c context.Context, taskId primitive.ObjectID, cascade models.TaskCascade) (models.TaskDeleteReport, error)
+	RestoreTask(c context.Context, taskId primitive.ObjectID) (models.TaskRestoreReport, error)
+	GetTasks(ctx context.Context, includeArchived bool) ([]models.Task, error)
+	GetTaskVersions(ctx context.Context, taskId primitive.ObjectID) ([]models.TaskVersion, error)
+	GetTaskAudience(ctx context.Context, taskId primitive.ObjectID) (models.TaskAudienceResponse, error)
+	GetUsers(ctx context.Context, filter models.StudentFilter) (models.PageResponse, error)
+	GetStudent(ctx context.Context, id primitive.ObjectID) (models.StudentResponse, error)
+	UpdateStudent(ctx context.Context, id primitive.ObjectID, student models.StudentDTO) error
+	DeactivateStudent(ctx context.Context, id primitive.ObjectID) error
+	ExportUsers(ctx context.Context, filter models.StudentFilter, w utils.TableWriter) error
+	ImportStudents(ctx context.Context, rows [][]string, dryRun bool) (models.ImportReport, error)
+	Search(ctx context.Context, query string, limit int64) ([]models.SearchResult, error)
+	GetTaskSubmissions(c context.Context, filter models.SubmissionFilter) (models.PageResponse, error)
+	ExportTaskSubmissions(ctx context.Context, filter models.SubmissionFilter, w utils.TableWriter) error
+	BulkEditTaskSubmissions(ctx context.Context, reviewerId primitive.ObjectID, submissionIds []primitive.ObjectID, status models.Status, feedback string) models.BulkReviewReport
+	EditTaskSubmission(ctx context.Context, reviewerId, submissionId primitive.ObjectID, status models.Status, feedback string) error
 	GetTaskSubmissionsForUser(ctx context.Context, userId primitive.ObjectID) ([]models.TaskSubmissionsAdminResponse, error)
 
 	CreateMentor(ctx context.Context, mentor models.MentorDTO) error
 	UpdateMentor(ctx context.Context, mentor models.MentorDTO) error
-	GetMentors(ctx context.Context) ([]models.MentorResponse, error)
+	GetMentors(ctx context.Context, includeArchived bool) ([]models.MentorResponse, error)
+	GetMentor(ctx context.Context, mentorId primitive.ObjectID) (models.MentorResponse, error)
+	DeleteMentor(ctx context.Context, mentorId primitive.ObjectID, detach models.MentorDetach) (models.MentorDeleteReport, error)
+	AssignMentor(ctx context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (models.MentorAssignmentReport, error)
+	UnassignMentor(ctx context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (models.MentorAssignmentReport, error)
+	GetMentorStudents(ctx context.Context, mentorId primitive.ObjectID) (models.MentorStudentsResponse, error)
+	AddMentorVideo(ctx context.Context, mentorId primitive.ObjectID, video models.VideoDTO) (models.Videos, error)
+	RemoveMentorVideo(ctx context.Context, mentorId, videoId primitive.ObjectID) error
+	ReorderMentorVideos(ctx context.Context, mentorId primitive.ObjectID, videoIds []primitive.ObjectID) ([]models.Videos, error)
+	GetVideos(ctx context.Context, domain string) ([]models.MentorVideo, error)
+	SetMentorAvailability(ctx context.Context, mentorId primitive.ObjectID, slots []models.AvailabilitySlot) error
+
+	CreateSession(ctx context.Context, creatorId primitive.ObjectID, session models.SessionDTO) (models.MentorSession, error)
+	CancelSession(ctx context.Context, sessionId primitive.ObjectID, reason string) (models.MentorSession, error)
+	GetSessions(ctx context.Context, filter models.SessionFilter) (models.PageResponse, error)
 
 	CreateDomain(ctx context.Context, domainString string) error
 	CreateCollege(ctx context.Context, college string) error
 	CreateCourse(ctx context.Context, course string) error
+	GetStaticData(ctx context.Context, kind models.StaticDataKind) ([]models.StaticModel, error)
+	RenameStaticData(ctx context.Context, kind models.StaticDataKind, from, to string) (models.StaticRenameReport, error)
+	DeleteStaticData(ctx context.Context, kind models.StaticDataKind, name string) error
 
 	GetData(ctx context.Context) (models.Data, error)
+
+	GetAuditEntries(ctx context.Context, filter models.AuditFilter) (models.PageResponse, error)
+	RecordUpload(ctx context.Context, url string)
 }
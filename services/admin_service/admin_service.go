@@ -3,8 +3,11 @@ package admin_service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/asishshaji/admin-api/models"
@@ -13,6 +16,8 @@ import (
 	"github.com/asishshaji/admin-api/utils"
 	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -33,170 +38,2011 @@ func NewAdminService(l *log.Logger, adminRepo admin_repository.IAdminRepository,
 	}
 }
 
-func (aS AdminService) Login(ctx context.Context, username, password string) (string, error) {
+const (
+	accessTokenDuration  = time.Minute * 15
+	refreshTokenDuration = time.Hour * 24 * 7
+	revokedTokenPrefix   = "revoked_token:"
+	tokensValidPrefix    = "tokens_valid_after:"
+
+	challengeTokenDuration = time.Minute * 5
+	recoveryCodeCount      = 10
+
+	passwordResetDuration = time.Hour
+	minPasswordLength     = 8
+
+	loginFailurePrefix    = "login_failures:"
+	loginLockPrefix       = "login_lock:"
+	loginFailureWindow    = time.Hour * 24
+	loginBackoffThreshold = 3
+	loginLockoutThreshold = 5
+	loginLockoutDuration  = time.Minute * 15
+	loginMaxLockDuration  = time.Hour * 24
+)
+
+// compared against when the username does not exist, so both failures take the same time
+var dummyPasswordHash, _ = utils.Hashpassword("dummy-password")
+
+func (aS AdminService) Login(ctx context.Context, username, password, ip string) (models.LoginResponse, error) {
+
+	retryAfter, err := aS.loginRetryAfter(ctx, username, ip)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if retryAfter > 0 {
+		return models.LoginResponse{}, models.LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	admin, err := aS.adminRepo.GetAdmin(ctx, username)
+
+	if err != nil {
+		utils.CheckpasswordHash(password, dummyPasswordHash)
+		aS.recordLoginFailure(ctx, username, ip)
+		return models.LoginResponse{}, models.ErrInvalidCredentials
+	}
+	authenticate := utils.CheckpasswordHash(password, admin.Password)
+
+	if !authenticate {
+		aS.recordLoginFailure(ctx, username, ip)
+		return models.LoginResponse{}, models.ErrInvalidCredentials
+	}
+
+	if admin.Disabled {
+		return models.LoginResponse{}, models.ErrAdminDisabled
+	}
+
+	aS.rehashPassword(ctx, admin, password)
+
+	if admin.TOTPEnabled {
+		return aS.generateChallenge(admin)
+	}
+
+	aS.clearLoginFailures(ctx, models.LOCKOUT_USERNAME, username)
+
+	tokens, err := aS.generateTokenPair(admin)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{TokenPair: &tokens}, nil
+}
+
+// rehashPassword upgrades hashes generated with a lower bcrypt cost, failures only get logged
+func (aS AdminService) rehashPassword(ctx context.Context, admin *models.Admin, password string) {
+	if !utils.NeedsRehash(admin.Password) {
+		return
+	}
+
+	hash, err := utils.Hashpassword(password)
+	if err != nil {
+		aS.l.Println(err)
+		return
+	}
+
+	if err = aS.adminRepo.UpdateAdminPassword(ctx, admin.ID, hash); err != nil {
+		aS.l.Println(err)
+	}
+}
+
+// ChangePassword signs the admin out everywhere, the current session included
+func (aS AdminService) ChangePassword(ctx context.Context, adminId primitive.ObjectID, currentPassword, newPassword string) error {
+	admin, err := aS.adminRepo.GetAdminById(ctx, adminId)
+	if err != nil {
+		return err
+	}
+
+	if !utils.CheckpasswordHash(currentPassword, admin.Password) {
+		return models.ErrInvalidCredentials
+	}
+
+	if err = aS.setPassword(ctx, adminId, newPassword); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "change_password", "admin", adminId.Hex(), nil, nil)
+
+	return nil
+}
+
+// CreatePasswordReset issues a single-use reset token for an admin. There is no mail
+// delivery, the superadmin hands the token to the admin who then calls ResetPassword.
+func (aS AdminService) CreatePasswordReset(ctx context.Context, actorId, adminId primitive.ObjectID) (models.PasswordResetResponse, error) {
+	if _, err := aS.adminRepo.GetAdminById(ctx, adminId); err != nil {
+		return models.PasswordResetResponse{}, err
+	}
+
+	token, tokenHash, err := utils.GenerateToken()
+	if err != nil {
+		aS.l.Println(err)
+		return models.PasswordResetResponse{}, err
+	}
+
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectIDFromTimestamp(time.Now()),
+		AdminId:   adminId,
+		TokenHash: tokenHash,
+		ExpiresAt: primitive.NewDateTimeFromTime(time.Now().Add(passwordResetDuration)),
+		CreatedBy: actorId,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err = aS.adminRepo.CreatePasswordReset(ctx, reset); err != nil {
+		return models.PasswordResetResponse{}, err
+	}
+
+	aS.audit(ctx, "create", "admin_password_resets", reset.ID.Hex(), nil, bson.M{
+		"admin_id":   adminId,
+		"expires_at": reset.ExpiresAt,
+	})
+
+	return models.PasswordResetResponse{
+		Token:     token,
+		ExpiresAt: reset.ExpiresAt,
+	}, nil
+}
+
+func (aS AdminService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if len(newPassword) < minPasswordLength {
+		return models.ErrWeakPassword
+	}
+
+	reset, err := aS.adminRepo.UsePasswordReset(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if err = aS.setPassword(ctx, reset.AdminId, newPassword); err != nil {
+		return err
+	}
+
+	// the reset runs outside of the /admin routes, the admin redeeming the token is the actor
+	aS.audit(utils.ContextWithAdminID(ctx, reset.AdminId), "reset_password", "admin", reset.AdminId.Hex(), nil, nil)
+
+	admin, err := aS.adminRepo.GetAdminById(ctx, reset.AdminId)
+	if err == nil {
+		aS.clearLoginFailures(ctx, models.LOCKOUT_USERNAME, admin.Username)
+	}
+
+	return nil
+}
+
+func (aS AdminService) setPassword(ctx context.Context, adminId primitive.ObjectID, password string) error {
+	if len(password) < minPasswordLength {
+		return models.ErrWeakPassword
+	}
+
+	hash, err := utils.Hashpassword(password)
+	if err != nil {
+		aS.l.Println(err)
+		return err
+	}
+
+	if err := aS.adminRepo.UpdateAdminPassword(ctx, adminId, hash); err != nil {
+		return err
+	}
+
+	return aS.invalidateSessions(ctx, adminId)
+}
+
+func (aS AdminService) generateChallenge(admin *models.Admin) (models.LoginResponse, error) {
+	now := time.Now()
+
+	challengeClaims := &models.AdminJWTClaims{
+		AdminId:   admin.ID,
+		TokenType: models.MFA_CHALLENGE,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			Subject:   admin.ID.Hex(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(challengeTokenDuration).Unix(),
+		},
+	}
+
+	challenge, err := utils.SignAdminToken(challengeClaims)
+	if err != nil {
+		aS.l.Println(err)
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		MFARequired:    true,
+		ChallengeToken: challenge,
+	}, nil
+}
+
+// VerifyLoginTOTP completes the second login step with either a TOTP code or an unused recovery code
+func (aS AdminService) VerifyLoginTOTP(ctx context.Context, challengeToken, code, recoveryCode, ip string) (models.LoginResponse, error) {
+	claims, err := utils.ParseAdminToken(challengeToken)
+	if err != nil || claims.TokenType != models.MFA_CHALLENGE || claims.Id == "" {
+		return models.LoginResponse{}, models.ErrInvalidToken
+	}
+
+	revoked, err := aS.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if revoked {
+		return models.LoginResponse{}, models.ErrTokenRevoked
+	}
+
+	admin, err := aS.adminRepo.GetAdminById(ctx, claims.AdminId)
+	if err != nil {
+		return models.LoginResponse{}, models.ErrInvalidToken
+	}
+
+	if issuedBefore(claims, admin.TokensValidAfter) {
+		return models.LoginResponse{}, models.ErrTokenRevoked
+	}
+
+	if admin.Disabled {
+		return models.LoginResponse{}, models.ErrAdminDisabled
+	}
+
+	retryAfter, err := aS.loginRetryAfter(ctx, admin.Username, ip)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if retryAfter > 0 {
+		return models.LoginResponse{}, models.LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	if err = aS.verifySecondFactor(ctx, admin, code, recoveryCode); err != nil {
+		aS.recordLoginFailure(ctx, admin.Username, ip)
+		return models.LoginResponse{}, err
+	}
+
+	if err = aS.revokeToken(ctx, claims); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	aS.clearLoginFailures(ctx, models.LOCKOUT_USERNAME, admin.Username)
+
+	tokens, err := aS.generateTokenPair(admin)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{TokenPair: &tokens}, nil
+}
+
+func (aS AdminService) verifySecondFactor(ctx context.Context, admin *models.Admin, code, recoveryCode string) error {
+	if !admin.TOTPEnabled {
+		return models.ErrTOTPNotEnabled
+	}
+
+	if code != "" {
+		step, ok := utils.ValidateTOTP(admin.TOTPSecret, code, time.Now())
+		if !ok {
+			return models.ErrInvalidTOTPCode
+		}
+
+		return aS.adminRepo.SetTOTPLastStep(ctx, admin.ID, step)
+	}
+
+	for _, hash := range admin.RecoveryCodes {
+		if utils.CheckpasswordHash(recoveryCode, hash) {
+			return aS.adminRepo.RemoveRecoveryCode(ctx, admin.ID, hash)
+		}
+	}
+
+	return models.ErrInvalidTOTPCode
+}
+
+// BeginTOTPEnrolment stores a new pending secret, it only becomes active once a code generated from it is verified
+func (aS AdminService) BeginTOTPEnrolment(ctx context.Context, adminId primitive.ObjectID) (models.TOTPEnrolment, error) {
+	admin, err := aS.adminRepo.GetAdminById(ctx, adminId)
+	if err != nil {
+		return models.TOTPEnrolment{}, err
+	}
+
+	if admin.TOTPEnabled {
+		return models.TOTPEnrolment{}, models.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		aS.l.Println(err)
+		return models.TOTPEnrolment{}, err
+	}
+
+	if err = aS.adminRepo.SetPendingTOTPSecret(ctx, adminId, secret); err != nil {
+		return models.TOTPEnrolment{}, err
+	}
+
+	return models.TOTPEnrolment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(totpIssuer(), admin.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrolment enables two-factor authentication and returns the recovery codes, they are only stored hashed
+func (aS AdminService) ConfirmTOTPEnrolment(ctx context.Context, adminId primitive.ObjectID, code string) ([]string, error) {
+	admin, err := aS.adminRepo.GetAdminById(ctx, adminId)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.TOTPEnabled {
+		return nil, models.ErrTOTPAlreadyEnabled
+	}
+
+	if admin.TOTPPendingSecret == "" {
+		return nil, models.ErrTOTPEnrolmentNotStarted
+	}
+
+	step, ok := utils.ValidateTOTP(admin.TOTPPendingSecret, code, time.Now())
+	if !ok {
+		return nil, models.ErrInvalidTOTPCode
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		aS.l.Println(err)
+		return nil, err
+	}
+
+	hashes := []string{}
+	for _, recoveryCode := range recoveryCodes {
+		hash, err := utils.Hashpassword(recoveryCode)
+		if err != nil {
+			aS.l.Println(err)
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if err = aS.adminRepo.EnableTOTP(ctx, adminId, admin.TOTPPendingSecret, step, hashes); err != nil {
+		return nil, err
+	}
+
+	aS.audit(ctx, "enable_totp", "admin", adminId.Hex(), admin.ToResponse(), aS.adminSnapshot(ctx, adminId))
+
+	return recoveryCodes, nil
+}
+
+func (aS AdminService) DisableTOTP(ctx context.Context, adminId primitive.ObjectID, password string) error {
+	admin, err := aS.adminRepo.GetAdminById(ctx, adminId)
+	if err != nil {
+		return err
+	}
+
+	if !utils.CheckpasswordHash(password, admin.Password) {
+		return models.ErrInvalidCredentials
+	}
+
+	if !admin.TOTPEnabled {
+		return models.ErrTOTPNotEnabled
+	}
+
+	if err = aS.adminRepo.DisableTOTP(ctx, adminId); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "disable_totp", "admin", adminId.Hex(), admin.ToResponse(), aS.adminSnapshot(ctx, adminId))
+
+	return nil
+}
+
+// ResetAdminTOTP lets a superadmin remove two-factor authentication for an admin who lost their device
+func (aS AdminService) ResetAdminTOTP(ctx context.Context, actorId, adminId primitive.ObjectID) error {
+	if actorId == adminId {
+		return models.ErrCannotModifySelf
+	}
+
+	before := aS.adminSnapshot(ctx, adminId)
+
+	if err := aS.adminRepo.DisableTOTP(ctx, adminId); err != nil {
+		return err
+	}
+
+	if err := aS.invalidateSessions(ctx, adminId); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "reset_totp", "admin", adminId.Hex(), before, aS.adminSnapshot(ctx, adminId))
+
+	return nil
+}
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "admin-api"
+}
+
+func loginKey(prefix string, scope models.LockoutScope, key string) string {
+	return prefix + scope.String() + ":" + key
+}
+
+// loginRetryAfter returns how long the username or ip is still locked out for
+func (aS AdminService) loginRetryAfter(ctx context.Context, username, ip string) (time.Duration, error) {
+	var retryAfter time.Duration
+
+	for _, key := range []string{
+		loginKey(loginLockPrefix, models.LOCKOUT_USERNAME, username),
+		loginKey(loginLockPrefix, models.LOCKOUT_IP, ip),
+	} {
+		ttl, err := aS.rClient.PTTL(ctx, key).Result()
+		if err != nil {
+			aS.l.Println(err)
+			return 0, err
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// recordLoginFailure counts a failed attempt for the username and the ip. From
+// loginBackoffThreshold failures on the next attempt is delayed by an exponentially
+// growing backoff, from loginLockoutThreshold the key is locked out for
+// loginLockoutDuration, doubling with every further failure.
+func (aS AdminService) recordLoginFailure(ctx context.Context, username, ip string) {
+	for scope, key := range map[models.LockoutScope]string{
+		models.LOCKOUT_USERNAME: username,
+		models.LOCKOUT_IP:       ip,
+	} {
+		failureKey := loginKey(loginFailurePrefix, scope, key)
+
+		failures, err := aS.rClient.Incr(ctx, failureKey).Result()
+		if err != nil {
+			aS.l.Println(err)
+			continue
+		}
+		aS.rClient.Expire(ctx, failureKey, loginFailureWindow)
+
+		lock := loginLockDuration(failures)
+		if lock == 0 {
+			continue
+		}
+
+		err = aS.rClient.Set(ctx, loginKey(loginLockPrefix, scope, key), failures, lock).Err()
+		if err != nil {
+			aS.l.Println(err)
+		}
+	}
+}
+
+func loginLockDuration(failures int64) time.Duration {
+	if failures < loginBackoffThreshold {
+		return 0
+	}
+
+	if failures < loginLockoutThreshold {
+		return time.Second * time.Duration(1<<(failures-loginBackoffThreshold+1))
+	}
+
+	lock := loginLockoutDuration
+	for i := int64(loginLockoutThreshold); i < failures && lock < loginMaxLockDuration; i++ {
+		lock *= 2
+	}
+	if lock > loginMaxLockDuration {
+		lock = loginMaxLockDuration
+	}
+
+	return lock
+}
+
+func (aS AdminService) clearLoginFailures(ctx context.Context, scope models.LockoutScope, key string) error {
+	err := aS.rClient.Del(ctx,
+		loginKey(loginFailurePrefix, scope, key),
+		loginKey(loginLockPrefix, scope, key),
+	).Err()
+	if err != nil {
+		aS.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (aS AdminService) GetLoginLockouts(ctx context.Context) ([]models.LoginLockout, error) {
+	lockouts := []models.LoginLockout{}
+
+	iter := aS.rClient.Scan(ctx, 0, loginLockPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		lockKey := iter.Val()
+
+		parts := strings.SplitN(strings.TrimPrefix(lockKey, loginLockPrefix), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		ttl, err := aS.rClient.PTTL(ctx, lockKey).Result()
+		if err != nil || ttl <= 0 {
+			continue
+		}
+
+		scope := models.LockoutScope(parts[0])
+		failures, _ := aS.rClient.Get(ctx, loginKey(loginFailurePrefix, scope, parts[1])).Int64()
+
+		lockouts = append(lockouts, models.LoginLockout{
+			Scope:      scope,
+			Key:        parts[1],
+			Failures:   failures,
+			RetryAfter: int64(ttl.Seconds()),
+		})
+	}
+
+	if err := iter.Err(); err != nil {
+		aS.l.Println(err)
+		return nil, err
+	}
+
+	return lockouts, nil
+}
+
+func (aS AdminService) ClearLoginLockout(ctx context.Context, scope models.LockoutScope, key string) error {
+	if err := aS.clearLoginFailures(ctx, scope, key); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "clear", "login_lockouts", scope.String()+":"+key, nil, nil)
+
+	return nil
+}
+
+func (aS AdminService) generateTokenPair(admin *models.Admin) (models.TokenPair, error) {
+	now := time.Now()
+
+	accessClaims := &models.AdminJWTClaims{
+		AdminId:     admin.ID,
+		IsAdmin:     true,
+		Role:        admin.Role,
+		Permissions: admin.Role.Permissions(),
+		TokenType:   models.ACCESS_TOKEN,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			Subject:   admin.ID.Hex(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(accessTokenDuration).Unix(),
+		},
+	}
+
+	refreshClaims := &models.AdminJWTClaims{
+		AdminId:   admin.ID,
+		TokenType: models.REFRESH_TOKEN,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			Subject:   admin.ID.Hex(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(refreshTokenDuration).Unix(),
+		},
+	}
+
+	accessToken, err := utils.SignAdminToken(accessClaims)
+	if err != nil {
+		aS.l.Println(err)
+		return models.TokenPair{}, err
+	}
+
+	refreshToken, err := utils.SignAdminToken(refreshClaims)
+	if err != nil {
+		aS.l.Println(err)
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenDuration.Seconds()),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair, the used refresh token is revoked
+func (aS AdminService) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	claims, err := utils.ParseAdminToken(refreshToken)
+	if err != nil || claims.TokenType != models.REFRESH_TOKEN || claims.Id == "" {
+		return models.TokenPair{}, models.ErrInvalidToken
+	}
+
+	revoked, err := aS.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if revoked {
+		return models.TokenPair{}, models.ErrTokenRevoked
+	}
+
+	admin, err := aS.adminRepo.GetAdminById(ctx, claims.AdminId)
+	if err != nil {
+		return models.TokenPair{}, models.ErrInvalidToken
+	}
+
+	if issuedBefore(claims, admin.TokensValidAfter) {
+		return models.TokenPair{}, models.ErrTokenRevoked
+	}
+
+	if admin.Disabled {
+		return models.TokenPair{}, models.ErrAdminDisabled
+	}
+
+	if err = aS.revokeToken(ctx, claims); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return aS.generateTokenPair(admin)
+}
+
+// Logout revokes the access token used for the request and, if given, the refresh token issued with it
+func (aS AdminService) Logout(ctx context.Context, accessClaims *models.AdminJWTClaims, refreshToken string) error {
+	if err := aS.revokeToken(ctx, accessClaims); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	refreshClaims, err := utils.ParseAdminToken(refreshToken)
+	if err != nil || refreshClaims.TokenType != models.REFRESH_TOKEN || refreshClaims.AdminId != accessClaims.AdminId {
+		return models.ErrInvalidToken
+	}
+
+	return aS.revokeToken(ctx, refreshClaims)
+}
+
+// IsTokenRevoked reports whether the token was revoked on its own or issued before the admin's
+// sessions were invalidated. Both are read from redis so requests do not hit the database,
+// Refresh also checks the admin's TokensValidAfter.
+func (aS AdminService) IsTokenRevoked(ctx context.Context, claims *models.AdminJWTClaims) (bool, error) {
+	n, err := aS.rClient.Exists(ctx, revokedTokenPrefix+claims.Id).Result()
+	if err != nil {
+		aS.l.Println(err)
+		return false, err
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	validAfter, err := aS.rClient.Get(ctx, tokensValidPrefix+claims.AdminId.Hex()).Int64()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		aS.l.Println(err)
+		return false, err
+	}
+
+	return claims.IssuedAt < validAfter, nil
+}
+
+// invalidateSessions makes every token issued to the admin so far unusable. The time is kept
+// to the second as token iat is, so tokens issued right after are still accepted.
+func (aS AdminService) invalidateSessions(ctx context.Context, adminId primitive.ObjectID) error {
+	at := time.Now().Truncate(time.Second)
+
+	if err := aS.adminRepo.SetAdminTokensValidAfter(ctx, adminId, primitive.NewDateTimeFromTime(at)); err != nil {
+		return err
+	}
+
+	// refresh tokens are the longest lived, older tokens have expired anyway
+	err := aS.rClient.Set(ctx, tokensValidPrefix+adminId.Hex(), at.Unix(), refreshTokenDuration).Err()
+	if err != nil {
+		aS.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func issuedBefore(claims *models.AdminJWTClaims, validAfter primitive.DateTime) bool {
+	return validAfter != 0 && claims.IssuedAt < validAfter.Time().Unix()
+}
+
+// revokeToken keeps the token id in the revocation list until the token would have expired anyway
+func (aS AdminService) revokeToken(ctx context.Context, claims *models.AdminJWTClaims) error {
+	if claims.Id == "" {
+		return models.ErrInvalidToken
+	}
+
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := aS.rClient.Set(ctx, revokedTokenPrefix+claims.Id, claims.AdminId.Hex(), ttl).Err()
+	if err != nil {
+		aS.l.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (aS AdminService) CreateAdmin(ctx context.Context, admin models.AdminDTO) error {
+	password, err := utils.Hashpassword(admin.Password)
+	if err != nil {
+		aS.l.Println(err)
+		return err
+	}
+
+	a := models.Admin{
+		ID:        primitive.NewObjectIDFromTimestamp(time.Now()),
+		Username:  admin.Username,
+		Password:  password,
+		Role:      admin.Role,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err = aS.adminRepo.CreateAdmin(ctx, a); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "create", "admin", a.ID.Hex(), nil, a.ToResponse())
+
+	return nil
+}
+
+func (aS AdminService) GetAdmins(ctx context.Context) ([]models.AdminResponse, error) {
+	admins, err := aS.adminRepo.GetAdmins(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	adminResponses := []models.AdminResponse{}
+
+	for _, admin := range admins {
+		adminResponses = append(adminResponses, admin.ToResponse())
+	}
+
+	return adminResponses, nil
+}
+
+func (aS AdminService) SetAdminDisabled(ctx context.Context, actorId, adminId primitive.ObjectID, disabled bool) error {
+	if actorId == adminId {
+		return models.ErrCannotModifySelf
+	}
+
+	before := aS.adminSnapshot(ctx, adminId)
+
+	if err := aS.adminRepo.SetAdminDisabled(ctx, adminId, disabled); err != nil {
+		return err
+	}
+
+	if disabled {
+		if err := aS.invalidateSessions(ctx, adminId); err != nil {
+			return err
+		}
+	}
+
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	aS.audit(ctx, action, "admin", adminId.Hex(), before, aS.adminSnapshot(ctx, adminId))
+
+	return nil
+}
+
+func (aS AdminService) DeleteAdmin(ctx context.Context, actorId, adminId primitive.ObjectID) error {
+	if actorId == adminId {
+		return models.ErrCannotModifySelf
+	}
+
+	before := aS.adminSnapshot(ctx, adminId)
+
+	if err := aS.invalidateSessions(ctx, adminId); err != nil {
+		return err
+	}
+
+	if err := aS.adminRepo.DeleteAdmin(ctx, adminId); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "delete", "admin", adminId.Hex(), before, nil)
+
+	return nil
+}
+
+// adminSnapshot is the audited view of an admin, it leaves out the password and two-factor secrets
+func (aS AdminService) adminSnapshot(ctx context.Context, adminId primitive.ObjectID) *models.AdminResponse {
+	admin, err := aS.adminRepo.GetAdminById(ctx, adminId)
+	if err != nil {
+		return nil
+	}
+
+	res := admin.ToResponse()
+	return &res
+}
+
+// audit records a mutation made by the admin in ctx. A failed write is logged and
+// does not fail the mutation, which has already been applied at this point.
+func (aS AdminService) audit(ctx context.Context, action, collection, entityId string, before, after interface{}) {
+	beforeDoc, err := utils.ToMap(before)
+	if err != nil {
+		aS.l.Println(err)
+	}
+
+	afterDoc, err := utils.ToMap(after)
+	if err != nil {
+		aS.l.Println(err)
+	}
+
+	entry := models.AuditEntry{
+		ID:         primitive.NewObjectIDFromTimestamp(time.Now()),
+		ActorId:    utils.AdminIDFromContext(ctx),
+		Action:     action,
+		Collection: collection,
+		EntityId:   entityId,
+		Before:     beforeDoc,
+		After:      afterDoc,
+		Diff:       utils.Diff(beforeDoc, afterDoc),
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err = aS.adminRepo.CreateAuditEntry(ctx, entry); err != nil {
+		aS.l.Println("Failed to write audit entry", action, collection, entityId, err)
+	}
+}
+
+func (aS AdminService) GetAuditEntries(ctx context.Context, filter models.AuditFilter) (models.PageResponse, error) {
+	filter.Normalize()
+
+	entries, total, err := aS.adminRepo.GetAuditEntries(ctx, filter)
+	if err != nil {
+		return models.PageResponse{}, err
+	}
+
+	return models.PageResponse{
+		Data:  entries,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
+}
+
+// RecordUpload audits a file upload, uploads do not touch a collection so only the url is kept
+func (aS AdminService) RecordUpload(ctx context.Context, url string) {
+	aS.audit(ctx, "upload", "files", url, nil, bson.M{"url": url})
+}
+
+func (aS AdminService) AddTask(ctx context.Context, task models.TaskDTO, creatorID primitive.ObjectID) error {
+	if err := aS.validateAssignment(ctx, task.Assignment); err != nil {
+		return err
+	}
+
+	t := task.ToTask()
+	t.CreatorID = creatorID
+	t.Id = primitive.NewObjectIDFromTimestamp(time.Now())
+	t.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	t.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	t.Version = 1
+
+	err := aS.adminRepo.AddTask(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	aS.saveTaskVersion(ctx, t, creatorID)
+	aS.audit(ctx, "create", "tasks", t.Id.Hex(), nil, t)
+
+	return nil
+}
+
+// UpdateTask saves the task as its next version, students who already submitted keep the
+// version they submitted against
+func (aS AdminService) UpdateTask(ctx context.Context, task models.TaskDTO) error {
+	tId, err := primitive.ObjectIDFromHex(task.ID)
+	if err != nil {
+		return models.ErrInvalidTaskId
+	}
+
+	if err := aS.validateAssignment(ctx, task.Assignment); err != nil {
+		return err
+	}
+
+	before, err := aS.adminRepo.GetTaskById(ctx, tId)
+	if err != nil {
+		return err
+	}
+
+	if before.ArchivedAt != 0 {
+		return models.ErrTaskArchived
+	}
+
+	// tasks created before versioning get their current state stored as version 1 first
+	version := before.Version
+	if version == 0 {
+		v1 := *before
+		v1.Version = 1
+		if v1.UpdatedAt == 0 {
+			v1.UpdatedAt = v1.CreatedAt
+		}
+		aS.saveTaskVersion(ctx, v1, v1.CreatorID)
+		version = 1
+	}
+
+	t := task.ToTask()
+	t.Id = tId
+	t.CreatorID = before.CreatorID
+	t.CreatedAt = before.CreatedAt
+	t.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	t.Version = version + 1
+
+	if err = aS.adminRepo.UpdateTask(ctx, t, before.Version); err != nil {
+		return err
+	}
+
+	// the snapshot is the stored task, t only holds what the edit sent. A later edit may have
+	// landed in between, then t is the closest there is to this version.
+	after, err := aS.adminRepo.GetTaskById(ctx, tId)
+	if err == nil && after.Version == t.Version {
+		aS.saveTaskVersion(ctx, *after, utils.AdminIDFromContext(ctx))
+	} else {
+		aS.saveTaskVersion(ctx, t, utils.AdminIDFromContext(ctx))
+	}
+
+	aS.audit(ctx, "update", "tasks", tId.Hex(), before, after)
+
+	return nil
+}
+
+// saveTaskVersion stores a snapshot of the task, a failure is logged since the task itself
+// is already saved
+func (aS AdminService) saveTaskVersion(ctx context.Context, task models.Task, editor primitive.ObjectID) {
+	err := aS.adminRepo.CreateTaskVersion(ctx, models.TaskVersion{
+		ID:        primitive.NewObjectID(),
+		TaskId:    task.Id,
+		Version:   task.Version,
+		Task:      task,
+		EditedBy:  editor,
+		CreatedAt: task.UpdatedAt,
+	})
+	if err != nil {
+		aS.l.Println("Error saving version", task.Version, "of task", task.Id.Hex(), err)
+	}
+}
+
+func (aS AdminService) GetTaskVersions(ctx context.Context, taskId primitive.ObjectID) ([]models.TaskVersion, error) {
+	if _, err := aS.adminRepo.GetTaskById(ctx, taskId); err != nil {
+		return nil, err
+	}
+
+	return aS.adminRepo.GetTaskVersions(ctx, taskId)
+}
+
+// validateAssignment checks every college, course and student of the assignment exists
+func (aS AdminService) validateAssignment(ctx context.Context, a models.TaskAssignment) error {
+	for _, college := range a.Colleges {
+		if _, err := aS.adminRepo.GetCollege(ctx, college); err != nil {
+			return fmt.Errorf("%w: unknown college %q", models.ErrInvalidAssignment, college)
+		}
+	}
+
+	for _, course := range a.Courses {
+		if _, err := aS.adminRepo.GetCourse(ctx, course); err != nil {
+			return fmt.Errorf("%w: unknown course %q", models.ErrInvalidAssignment, course)
+		}
+	}
+
+	for _, id := range a.StudentIds {
+		if _, err := aS.adminRepo.GetStudentById(ctx, id); err != nil {
+			return fmt.Errorf("%w: unknown student %q", models.ErrInvalidAssignment, id.Hex())
+		}
+	}
+
+	return nil
+}
+
+func (aS AdminService) GetTaskAudience(ctx context.Context, taskId primitive.ObjectID) (models.TaskAudienceResponse, error) {
+	task, err := aS.adminRepo.GetTaskById(ctx, taskId)
+	if err != nil {
+		return models.TaskAudienceResponse{}, err
+	}
+
+	students, err := aS.adminRepo.GetTaskAudience(ctx, *task)
+	if err != nil {
+		return models.TaskAudienceResponse{}, err
+	}
+
+	res := models.TaskAudienceResponse{
+		TaskId:   taskId,
+		Total:    len(students),
+		Students: students,
+	}
+	for _, s := range students {
+		if s.SubmissionStatus != models.INACTIVE {
+			res.Submitted++
+		}
+	}
+
+	return res, nil
+}
+
+func (aS AdminService) GetStudent(ctx context.Context, id primitive.ObjectID) (models.StudentResponse, error) {
+	student, err := aS.adminRepo.GetStudentById(ctx, id)
+	if err != nil {
+		return models.StudentResponse{}, err
+	}
+
+	return student.ToResponse(), nil
+}
+
+func (aS AdminService) UpdateStudent(ctx context.Context, id primitive.ObjectID, student models.StudentDTO) error {
+	before, err := aS.adminRepo.GetStudentById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if student.Email != before.Email {
+		if _, err := aS.adminRepo.GetStudentByEmail(ctx, student.Email); err == nil {
+			return models.ErrStudentEmailTaken
+		}
+	}
+
+	s := student.ToStudent()
+	s.ID = id
+	s.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	if err = aS.adminRepo.UpdateStudent(ctx, s); err != nil {
+		return err
+	}
+
+	after, err := aS.adminRepo.GetStudentById(ctx, id)
+	if err == nil {
+		aS.audit(ctx, "update", "students", id.Hex(), before.ToResponse(), after.ToResponse())
+	}
+
+	return nil
+}
+
+func (aS AdminService) DeactivateStudent(ctx context.Context, id primitive.ObjectID) error {
+	before, err := aS.adminRepo.GetStudentById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = aS.adminRepo.DeactivateStudent(ctx, id); err != nil {
+		return err
+	}
+
+	after, err := aS.adminRepo.GetStudentById(ctx, id)
+	if err == nil {
+		aS.audit(ctx, "deactivate", "students", id.Hex(), before.ToResponse(), after.ToResponse())
+	}
+
+	return nil
+}
+
+// ImportStudents creates a student for every valid row, the first row is the header. Rows whose
+// email is already registered or repeated in the file are skipped. On a dry run nothing is
+// written and valid rows are reported as such.
+func (aS AdminService) ImportStudents(ctx context.Context, rows [][]string, dryRun bool) (models.ImportReport, error) {
+	report := models.ImportReport{DryRun: dryRun, Rows: []models.ImportRowResult{}}
+
+	if len(rows) < 2 {
+		return report, models.ErrEmptyImport
+	}
+
+	colleges, err := aS.staticNames(aS.adminRepo.GetColleges(ctx))
+	if err != nil {
+		return report, err
+	}
+	courses, err := aS.staticNames(aS.adminRepo.GetCourses(ctx))
+	if err != nil {
+		return report, err
+	}
+	domains, err := aS.staticNames(aS.adminRepo.GetDomains(ctx))
+	if err != nil {
+		return report, err
+	}
+
+	header := rows[0]
+	seen := map[string]bool{}
+
+	for i, row := range rows[1:] {
+		// row numbers match the spreadsheet, the header is row 1
+		result := models.ImportRowResult{Row: i + 2}
+
+		dto, err := models.StudentDTOFromRow(header, row)
+		result.Email = dto.Email
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		} else if err = dto.Validate(); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+
+		if dto.College != "" && !colleges[dto.College] {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown college %q", dto.College))
+		}
+		if dto.Course != "" && !courses[dto.Course] {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown course %q", dto.Course))
+		}
+		for _, domain := range dto.Domains {
+			if !domains[domain] {
+				result.Errors = append(result.Errors, fmt.Sprintf("unknown domain %q", domain))
+			}
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			result.Status = models.ROW_ERROR
+		case seen[dto.Email]:
+			result.Status = models.ROW_SKIPPED
+			result.Errors = append(result.Errors, "duplicate email in file")
+		default:
+			seen[dto.Email] = true
+
+			if _, err := aS.adminRepo.GetStudentByEmail(ctx, dto.Email); err == nil {
+				result.Status = models.ROW_SKIPPED
+				result.Errors = append(result.Errors, models.ErrStudentEmailTaken.Error())
+			} else if dryRun {
+				result.Status = models.ROW_VALID
+			} else if err := aS.importStudent(ctx, dto); err == models.ErrStudentExists {
+				result.Status = models.ROW_SKIPPED
+				result.Errors = append(result.Errors, err.Error())
+			} else if err != nil {
+				result.Status = models.ROW_ERROR
+				result.Errors = append(result.Errors, err.Error())
+			} else {
+				result.Status = models.ROW_CREATED
+			}
+		}
+
+		switch result.Status {
+		case models.ROW_CREATED:
+			report.Created++
+		case models.ROW_SKIPPED:
+			report.Skipped++
+		case models.ROW_ERROR:
+			report.Errored++
+		}
+
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, nil
+}
+
+func (aS AdminService) importStudent(ctx context.Context, dto models.StudentDTO) error {
+	hash, err := utils.Hashpassword(dto.Password)
+	if err != nil {
+		return err
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	student := dto.ToStudent()
+	student.ID = primitive.NewObjectID()
+	student.Password = hash
+	student.Status = models.STUDENT_ACTIVE
+	student.CreatedAt = now
+	student.UpdatedAt = now
+
+	if err = aS.adminRepo.CreateStudent(ctx, student); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "import", "students", student.ID.Hex(), nil, student.ToResponse())
+
+	return nil
+}
+
+func (aS AdminService) staticNames(entities []models.StaticModel, err error) (map[string]bool, error) {
+	names := map[string]bool{}
+	if err != nil {
+		return names, err
+	}
+
+	for _, e := range entities {
+		names[e.Name] = true
+	}
+
+	return names, nil
+}
+
+const defaultSearchLimit = 20
+
+// Search returns the matching students, tasks and mentors ranked by their text score
+func (aS AdminService) Search(ctx context.Context, query string, limit int64) ([]models.SearchResult, error) {
+	if limit < 1 || limit > defaultSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	results, err := aS.adminRepo.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if int64(len(results)) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func (aS AdminService) GetTasks(ctx context.Context, includeArchived bool) ([]models.Task, error) {
+	return aS.adminRepo.GetTasks(ctx, includeArchived)
+}
+
+func (aS AdminService) GetUsers(ctx context.Context, filter models.StudentFilter) (models.PageResponse, error) {
+	filter.Normalize()
+
+	studentModels, total, err := aS.adminRepo.GetUsers(ctx, filter)
+	if err != nil {
+		return models.PageResponse{}, err
+	}
+
+	studentResponse := studentModels.ToStudentResponse()
+
+	return models.PageResponse{
+		Data:  studentResponse,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
+}
+
+// ExportUsers writes every student matching the filter, pagination is ignored
+func (aS AdminService) ExportUsers(ctx context.Context, filter models.StudentFilter, w utils.TableWriter) error {
+	if err := w.WriteRow(models.StudentExportHeader); err != nil {
+		return err
+	}
+
+	return aS.adminRepo.StreamUsers(ctx, filter, func(student models.Student) error {
+		return w.WriteRow(student.ToResponse().ExportRow())
+	})
+}
+
+func (aS AdminService) ExportTaskSubmissions(ctx context.Context, filter models.SubmissionFilter, w utils.TableWriter) error {
+	if err := w.WriteRow(models.TaskSubmissionExportHeader); err != nil {
+		return err
+	}
+
+	return aS.adminRepo.StreamTaskSubmissions(ctx, filter, func(submission models.TaskSubmissionsAdminResponse) error {
+		return w.WriteRow(submission.ExportRow())
+	})
+}
+
+// DeleteTask archives the task, or deletes it for good with the force cascade, see
+// models.TaskCascade for what happens to its submissions
+func (aS AdminService) DeleteTask(c context.Context, taskId primitive.ObjectID, cascade models.TaskCascade) (models.TaskDeleteReport, error) {
+	report := models.TaskDeleteReport{TaskId: taskId, Cascade: cascade}
+
+	before, err := aS.adminRepo.GetTaskById(c, taskId)
+	if err != nil {
+		return report, err
+	}
+
+	if cascade == models.CASCADE_FORCE {
+		// the submissions go first, so a failure leaves a task without submissions rather than
+		// submissions without a task
+		report.SubmissionsDeleted, err = aS.adminRepo.DeleteTaskSubmissions(c, taskId)
+		if err != nil {
+			return report, err
+		}
+		aS.audit(c, "delete", "task_submission", taskId.Hex(), nil, report)
+
+		if err := aS.adminRepo.DeleteTask(c, taskId); err != nil {
+			return report, err
+		}
+
+		report.Task = "deleted"
+		aS.audit(c, "delete", "tasks", taskId.Hex(), before, nil)
+
+		return report, nil
+	}
+
+	submissions, err := aS.adminRepo.CountTaskSubmissions(c, taskId)
+	if err != nil {
+		return report, err
+	}
+
+	if cascade == models.CASCADE_BLOCK && submissions > 0 {
+		return report, models.TaskHasSubmissionsError{Submissions: submissions}
+	}
+
+	archivedAt := primitive.NewDateTimeFromTime(time.Now())
+	if err := aS.adminRepo.ArchiveTask(c, taskId, archivedAt); err != nil {
+		return report, err
+	}
+	report.Task = "archived"
+
+	if cascade == models.CASCADE_ARCHIVE {
+		report.SubmissionsArchived, err = aS.adminRepo.ArchiveTaskSubmissions(c, taskId, archivedAt)
+		if err != nil {
+			return report, err
+		}
+		aS.audit(c, "archive", "task_submission", taskId.Hex(), nil, report)
+	}
+
+	after, _ := aS.adminRepo.GetTaskById(c, taskId)
+	aS.audit(c, "archive", "tasks", taskId.Hex(), before, after)
+
+	return report, nil
+}
+
+// RestoreTask brings back an archived task and the submissions archived along with it
+func (aS AdminService) RestoreTask(c context.Context, taskId primitive.ObjectID) (models.TaskRestoreReport, error) {
+	report := models.TaskRestoreReport{TaskId: taskId}
+
+	before, err := aS.adminRepo.GetTaskById(c, taskId)
+	if err != nil {
+		return report, err
+	}
+
+	if before.ArchivedAt == 0 {
+		return report, models.ErrTaskNotArchived
+	}
+
+	if err = aS.adminRepo.RestoreTask(c, taskId); err != nil {
+		return report, err
+	}
+
+	report.SubmissionsRestored, err = aS.adminRepo.RestoreTaskSubmissions(c, taskId, before.ArchivedAt)
+	if err != nil {
+		return report, err
+	}
+
+	after, _ := aS.adminRepo.GetTaskById(c, taskId)
+	aS.audit(c, "restore", "tasks", taskId.Hex(), before, after)
+
+	return report, nil
+}
+
+func (aS AdminService) GetTaskSubmissions(c context.Context, filter models.SubmissionFilter) (models.PageResponse, error) {
+	filter.Normalize()
+
+	submissions, total, err := aS.adminRepo.GetTaskSubmissions(c, filter)
+	if err != nil {
+		return models.PageResponse{}, err
+	}
+
+	return models.PageResponse{
+		Data:  submissions,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
+}
+
+// submissionTransitions lists the statuses a review can move a submission to. Students move
+// inactive and needs_revision submissions to active by submitting, which is not done here.
+var submissionTransitions = map[models.Status][]models.Status{
+	models.INACTIVE:       {},
+	models.ACTIVE:         {models.COMPLETED, models.REJECTED, models.NEEDS_REVISION},
+	models.NEEDS_REVISION: {models.COMPLETED, models.REJECTED},
+	models.REJECTED:       {models.NEEDS_REVISION},
+	models.COMPLETED:      {},
+}
+
+func checkSubmissionTransition(from, to models.Status) error {
+	allowed := submissionTransitions[from]
+	for _, s := range allowed {
+		if s == to {
+			return nil
+		}
+	}
+
+	return models.InvalidTransitionError{From: from, To: to, Allowed: allowed}
+}
+
+// EditTaskSubmission records a review on the submission and notifies the student, the
+// reviewer's feedback is the notification content
+func (aS AdminService) EditTaskSubmission(ctx context.Context, reviewerId, submissionId primitive.ObjectID, status models.Status, feedback string) error {
+	submission, err := aS.reviewSubmission(ctx, reviewerId, submissionId, status, feedback)
+	if err != nil {
+		return err
+	}
+
+	title := "Your task is " + status.String()
+
+	return aS.notifyStudent(ctx, submission.UserId, title, reviewContent(title, feedback))
+}
+
+// BulkEditTaskSubmissions reviews every submission on its own so one failure does not undo the
+// rest, each affected student gets a single notification for all of their reviewed submissions
+func (aS AdminService) BulkEditTaskSubmissions(ctx context.Context, reviewerId primitive.ObjectID, submissionIds []primitive.ObjectID, status models.Status, feedback string) models.BulkReviewReport {
+	report := models.BulkReviewReport{Results: []models.BulkReviewResult{}}
+
+	reviewed := map[primitive.ObjectID]int{}
+	students := []primitive.ObjectID{}
+
+	for _, id := range submissionIds {
+		result := models.BulkReviewResult{SubmissionId: id}
+
+		submission, err := aS.reviewSubmission(ctx, reviewerId, id, status, feedback)
+		if err != nil {
+			result.Error = err.Error()
+			report.Failed++
+		} else {
+			result.Reviewed = true
+			report.Reviewed++
+
+			if reviewed[submission.UserId] == 0 {
+				students = append(students, submission.UserId)
+			}
+			reviewed[submission.UserId]++
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	for _, uid := range students {
+		title := "Your task is " + status.String()
+		if n := reviewed[uid]; n > 1 {
+			title = fmt.Sprintf("%d of your tasks are %s", n, status.String())
+		}
+
+		if err := aS.notifyStudent(ctx, uid, title, reviewContent(title, feedback)); err != nil {
+			aS.l.Println(err)
+		}
+	}
+
+	return report
+}
+
+// reviewSubmission applies a single review and returns the submission as it was before it
+func (aS AdminService) reviewSubmission(ctx context.Context, reviewerId, submissionId primitive.ObjectID, status models.Status, feedback string) (*models.TaskSubmission, error) {
+	before, err := aS.adminRepo.GetTaskSubmissionById(ctx, submissionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if before.ArchivedAt != 0 {
+		return nil, models.ErrSubmissionArchived
+	}
+
+	if err = checkSubmissionTransition(before.Status, status); err != nil {
+		return nil, err
+	}
+
+	review := models.SubmissionReview{
+		ReviewerId: reviewerId,
+		OldStatus:  before.Status,
+		NewStatus:  status,
+		Feedback:   strings.TrimSpace(feedback),
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err = aS.adminRepo.ReviewTaskSubmission(ctx, submissionId, review); err != nil {
+		return nil, err
+	}
+
+	after, _ := aS.adminRepo.GetTaskSubmissionById(ctx, submissionId)
+	aS.audit(ctx, "review", "task_submission", submissionId.Hex(), before, after)
+
+	return before, nil
+}
+
+func reviewContent(title, feedback string) string {
+	if content := strings.TrimSpace(feedback); content != "" {
+		return content
+	}
+	return title
+}
+
+// notifyStudent sends a push notification and stores it for the student's inbox
+func (aS AdminService) notifyStudent(ctx context.Context, uid primitive.ObjectID, title, content string) error {
+	tK, err := aS.adminRepo.GetToken(ctx, uid)
+	if err != nil {
+		aS.l.Println(err)
+	}
+
+	msg := models.NotificationMessage{
+		UserToken: tK.Token,
+		Heading:   map[string]string{"en": title},
+		Contents:  map[string]string{"en": content},
+	}
+
+	err = aS.notificationService.SendNotification(ctx, msg)
+	if err != nil {
+		aS.l.Println(err)
+	}
+
+	err = aS.adminRepo.CreateNotification(ctx, models.NotificationEntity{
+		UserId:    uid,
+		Content:   content,
+		Title:     title,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		Image:     "",
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (aS AdminService) GetTaskSubmissionsForUser(ctx context.Context, userId primitive.ObjectID) ([]models.TaskSubmissionsAdminResponse, error) {
+
+	return aS.adminRepo.GetTaskSubmissionsForUser(ctx, userId)
+
+}
+
+func (aS AdminService) CreateMentor(ctx context.Context, mentor models.MentorDTO) error {
+
+	m := mentor.ToMentor()
+	m.ID = primitive.NewObjectIDFromTimestamp(time.Now())
+	m.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	m.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	if err := aS.adminRepo.CreateMentor(ctx, m); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "create", "mentor", m.ID.Hex(), nil, m)
+
+	return nil
+}
+
+func (aS AdminService) UpdateMentor(ctx context.Context, mentor models.MentorDTO) error {
+
+	m := mentor.ToMentor()
+	m.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	before, _ := aS.adminRepo.GetMentorById(ctx, m.ID)
+	if before != nil && before.ArchivedAt != 0 {
+		return models.ErrMentorArchived
+	}
+
+	if err := aS.adminRepo.UpdateMentor(ctx, m); err != nil {
+		return err
+	}
 
-	admin, err := aS.adminRepo.GetAdmin(ctx, username)
+	after, _ := aS.adminRepo.GetMentorById(ctx, m.ID)
+	aS.audit(ctx, "update", "mentor", m.ID.Hex(), before, after)
+
+	return nil
+}
 
+func (aS AdminService) GetMentors(ctx context.Context, includeArchived bool) ([]models.MentorResponse, error) {
+
+	mentors, err := aS.adminRepo.GetMentors(ctx, includeArchived)
 	if err != nil {
-		return "", models.ErrNoAdminWithUsername
+		return nil, err
 	}
-	authenticate := utils.CheckpasswordHash(password, admin.Password)
 
-	if !authenticate {
-		return "", models.ErrInvalidCredentials
-	}
+	mentorResponses := []models.MentorResponse{}
 
-	adminClaims := &models.AdminJWTClaims{
-		admin.ID,
-		true,
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 72).Unix(),
-		},
+	for _, dto := range mentors {
+		mentorResponses = append(mentorResponses, *dto.ToResponse())
 	}
-	tokenMethod := jwt.NewWithClaims(jwt.SigningMethodHS256, adminClaims)
-	t, err := tokenMethod.SignedString([]byte(os.Getenv("JWT_SECRET")))
 
+	return mentorResponses, nil
+}
+
+func (aS AdminService) GetMentor(ctx context.Context, mentorId primitive.ObjectID) (models.MentorResponse, error) {
+	mentor, err := aS.adminRepo.GetMentorById(ctx, mentorId)
 	if err != nil {
-		aS.l.Println(err)
-		return "", err
+		return models.MentorResponse{}, err
 	}
 
-	return t, nil
+	return *mentor.ToResponse(), nil
 }
 
-func (aS AdminService) AddTask(ctx context.Context, task models.TaskDTO, creatorID primitive.ObjectID) error {
+// DeleteMentor archives the mentor. With DETACH_BLOCK it is refused while active students are
+// assigned, otherwise the mentor is removed from their students.
+func (aS AdminService) DeleteMentor(ctx context.Context, mentorId primitive.ObjectID, detach models.MentorDetach) (models.MentorDeleteReport, error) {
+	report := models.MentorDeleteReport{MentorId: mentorId, Detach: detach}
 
-	t := task.ToTask()
-	t.CreatorID = creatorID
-	t.Id = primitive.NewObjectIDFromTimestamp(time.Now())
-	t.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
-	t.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	before, err := aS.adminRepo.GetMentorById(ctx, mentorId)
+	if err != nil {
+		return report, err
+	}
 
-	err := aS.adminRepo.AddTask(ctx, t)
+	if before.ArchivedAt != 0 {
+		return report, models.ErrMentorArchived
+	}
+
+	if detach == models.DETACH_BLOCK {
+		students, err := aS.adminRepo.GetMentorStudents(ctx, mentorId)
+		if err != nil {
+			return report, err
+		}
+		if len(students) > 0 {
+			return report, models.MentorHasStudentsError{Students: students.ToStudentResponse()}
+		}
+	}
+
+	if err := aS.adminRepo.ArchiveMentor(ctx, mentorId, primitive.NewDateTimeFromTime(time.Now())); err != nil {
+		return report, err
+	}
+
+	// deactivated students still hold the reference when blocking, so detach in both cases
+	report.StudentsDetached, err = aS.adminRepo.DetachMentor(ctx, mentorId)
 	if err != nil {
-		return err
+		return report, err
 	}
 
-	return nil
+	after, _ := aS.adminRepo.GetMentorById(ctx, mentorId)
+	aS.audit(ctx, "archive", "mentor", mentorId.Hex(), before, after)
+
+	return report, nil
 }
 
-func (aS AdminService) UpdateTask(ctx context.Context, task models.TaskDTO) error {
+func (aS AdminService) AddMentorVideo(ctx context.Context, mentorId primitive.ObjectID, dto models.VideoDTO) (models.Videos, error) {
+	mentor, err := aS.adminRepo.GetMentorById(ctx, mentorId)
+	if err != nil {
+		return models.Videos{}, err
+	}
 
-	tId, _ := primitive.ObjectIDFromHex(task.ID)
+	if mentor.ArchivedAt != 0 {
+		return models.Videos{}, models.ErrMentorArchived
+	}
 
-	t := task.ToTask()
-	t.Id = tId
-	t.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	if dto.VideoUrl == "" {
+		return models.Videos{}, models.ErrVideoRequired
+	}
 
-	err := aS.adminRepo.UpdateTask(ctx, t)
-	if err != nil {
-		return err
+	if dto.Domain == "" {
+		dto.Domain = mentor.Domain
+	} else if _, err := aS.adminRepo.GetDomain(ctx, dto.Domain); err != nil {
+		return models.Videos{}, models.ErrInvalidVideoDomain
 	}
-	return nil
+
+	video := models.Videos{
+		Id:          primitive.NewObjectID(),
+		Title:       dto.Title,
+		Description: dto.Description,
+		Duration:    dto.Duration,
+		Domain:      dto.Domain,
+		ThumbUrl:    dto.ThumbUrl,
+		VideoUrl:    dto.VideoUrl,
+		CreatedAt:   primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err := aS.adminRepo.AddMentorVideo(ctx, mentorId, video); err != nil {
+		return models.Videos{}, err
+	}
+
+	aS.audit(ctx, "add_video", "mentor", mentorId.Hex(), nil, video)
+
+	return video, nil
 }
 
-func (aS AdminService) GetTasks(ctx context.Context) ([]models.Task, error) {
-	return aS.adminRepo.GetTasks(ctx)
+func (aS AdminService) RemoveMentorVideo(ctx context.Context, mentorId, videoId primitive.ObjectID) error {
+	if err := aS.adminRepo.RemoveMentorVideo(ctx, mentorId, videoId); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "remove_video", "mentor", mentorId.Hex(), bson.M{"video_id": videoId}, nil)
+
+	return nil
 }
 
-func (aS AdminService) GetUsers(ctx context.Context) ([]models.StudentResponse, error) {
-	studentModels, err := aS.adminRepo.GetUsers(ctx)
+// ReorderMentorVideos puts the videos of a mentor in the order of videoIds, which must name
+// every video of the mentor once
+func (aS AdminService) ReorderMentorVideos(ctx context.Context, mentorId primitive.ObjectID, videoIds []primitive.ObjectID) ([]models.Videos, error) {
+	mentor, err := aS.adminRepo.GetMentorById(ctx, mentorId)
 	if err != nil {
 		return nil, err
 	}
 
-	studentResponse := studentModels.ToStudentResponse()
+	if len(videoIds) != len(mentor.Videos) {
+		return nil, models.ErrInvalidVideoOrder
+	}
 
-	return studentResponse, nil
-}
+	if len(videoIds) == 0 {
+		return []models.Videos{}, nil
+	}
 
-func (aS AdminService) DeleteTask(c context.Context, taskId primitive.ObjectID) error {
-	return aS.adminRepo.DeleteTask(c, taskId)
+	byId := make(map[primitive.ObjectID]models.Videos, len(mentor.Videos))
+	expected := make([]primitive.ObjectID, 0, len(mentor.Videos))
+	for _, video := range mentor.Videos {
+		byId[video.Id] = video
+		expected = append(expected, video.Id)
+	}
+
+	videos := make([]models.Videos, 0, len(videoIds))
+	for _, id := range videoIds {
+		video, ok := byId[id]
+		if !ok {
+			return nil, models.ErrInvalidVideoOrder
+		}
+		delete(byId, id)
+		videos = append(videos, video)
+	}
+
+	if err := aS.adminRepo.SetMentorVideos(ctx, mentorId, expected, videos); err != nil {
+		return nil, err
+	}
+
+	aS.audit(ctx, "reorder_videos", "mentor", mentorId.Hex(), bson.M{"video_ids": expected}, bson.M{"video_ids": videoIds})
+
+	return videos, nil
 }
 
-func (aS AdminService) GetTaskSubmissions(c context.Context) ([]models.TaskSubmissionsAdminResponse, error) {
-	return aS.adminRepo.GetTaskSubmissions(c)
+func (aS AdminService) GetVideos(ctx context.Context, domain string) ([]models.MentorVideo, error) {
+	return aS.adminRepo.GetVideos(ctx, domain)
 }
-func (aS AdminService) EditTaskSubmission(ctx context.Context, uid primitive.ObjectID, taskId primitive.ObjectID, status models.Status) error {
 
-	err := aS.adminRepo.EditTaskSubmissionStatus(ctx, status, taskId)
+func (aS AdminService) SetMentorAvailability(ctx context.Context, mentorId primitive.ObjectID, slots []models.AvailabilitySlot) error {
+	before, err := aS.adminRepo.GetMentorById(ctx, mentorId)
 	if err != nil {
 		return err
 	}
 
-	tK, err := aS.adminRepo.GetToken(ctx, uid)
+	if before.ArchivedAt != 0 {
+		return models.ErrMentorArchived
+	}
+
+	if err := aS.adminRepo.SetMentorAvailability(ctx, mentorId, slots); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "set_availability", "mentor", mentorId.Hex(), bson.M{"availability": before.Availability}, bson.M{"availability": slots})
+
+	return nil
+}
+
+// CreateSession books a session inside one of the mentor's availability slots. Overlapping
+// bookings are checked before and after the insert, when two bookings race the older one is
+// kept.
+func (aS AdminService) CreateSession(ctx context.Context, creatorId primitive.ObjectID, dto models.SessionDTO) (models.MentorSession, error) {
+	mentor, err := aS.adminRepo.GetMentorById(ctx, dto.MentorId)
 	if err != nil {
-		aS.l.Println(err)
+		return models.MentorSession{}, err
 	}
 
-	title := "Your task is " + status.String()
-	content := "Dolor aliquip labore incididunt id dolore culpa. Sunt velit cillum magna ad. Voluptate est cupidatat excepteur ut voluptate. Et anim incididunt cillum occaecat anim laboris ullamco dolor."
+	if mentor.ArchivedAt != 0 {
+		return models.MentorSession{}, models.ErrMentorArchived
+	}
 
-	msg := models.NotificationMessage{
-		UserToken: tK.Token,
-		Heading:   map[string]string{"en": title},
-		Contents:  map[string]string{"en": content},
+	student, err := aS.adminRepo.GetStudentById(ctx, dto.StudentId)
+	if err != nil {
+		return models.MentorSession{}, err
 	}
 
-	err = aS.notificationService.SendNotification(ctx, msg)
+	if student.Status == models.STUDENT_DEACTIVATED {
+		return models.MentorSession{}, models.ErrStudentDeactivated
+	}
+
+	timeZone := ""
+	for _, slot := range mentor.Availability {
+		if slot.Contains(dto.StartAt, dto.EndAt) {
+			timeZone = slot.TimeZone
+			break
+		}
+	}
+	if timeZone == "" {
+		return models.MentorSession{}, models.ErrOutsideAvailability
+	}
+
+	session := models.MentorSession{
+		ID:        primitive.NewObjectID(),
+		MentorId:  dto.MentorId,
+		StudentId: dto.StudentId,
+		Topic:     dto.Topic,
+		StartAt:   primitive.NewDateTimeFromTime(dto.StartAt),
+		EndAt:     primitive.NewDateTimeFromTime(dto.EndAt),
+		TimeZone:  timeZone,
+		Status:    models.SESSION_SCHEDULED,
+		CreatedBy: creatorId,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	conflicts, err := aS.adminRepo.GetSessionConflicts(ctx, session)
+	if err != nil {
+		return models.MentorSession{}, err
+	}
+	if len(conflicts) > 0 {
+		return models.MentorSession{}, models.SessionConflictError{Sessions: conflicts}
+	}
+
+	if err := aS.adminRepo.CreateSession(ctx, session); err != nil {
+		return models.MentorSession{}, err
+	}
+
+	conflicts, err = aS.adminRepo.GetSessionConflicts(ctx, session)
 	if err != nil {
+		return models.MentorSession{}, err
+	}
+	if len(conflicts) > 0 && conflicts[0].ID.Hex() < session.ID.Hex() {
+		if err := aS.adminRepo.DeleteSession(ctx, session.ID); err != nil {
+			return models.MentorSession{}, err
+		}
+		return models.MentorSession{}, models.SessionConflictError{Sessions: conflicts}
+	}
+
+	aS.audit(ctx, "create", "mentor_sessions", session.ID.Hex(), nil, session)
+
+	content := fmt.Sprintf("Your session with %s is booked for %s", mentor.Name, sessionTime(session))
+	if err := aS.notifyStudent(ctx, session.StudentId, "Mentor session booked", content); err != nil {
 		aS.l.Println(err)
 	}
 
-	err = aS.adminRepo.CreateNotification(ctx, models.NotificationEntity{
-		UserId:    uid,
-		Content:   content,
-		Title:     title,
-		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
-		Image:     "",
-	})
+	return session, nil
+}
 
+func (aS AdminService) CancelSession(ctx context.Context, sessionId primitive.ObjectID, reason string) (models.MentorSession, error) {
+	before, err := aS.adminRepo.GetSessionById(ctx, sessionId)
 	if err != nil {
-		return err
+		return models.MentorSession{}, err
 	}
 
-	return nil
+	if err := aS.adminRepo.CancelSession(ctx, sessionId, reason); err != nil {
+		return models.MentorSession{}, err
+	}
+
+	after, err := aS.adminRepo.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return models.MentorSession{}, err
+	}
+
+	aS.audit(ctx, "cancel", "mentor_sessions", sessionId.Hex(), before, after)
+
+	mentorName := "your mentor"
+	if mentor, err := aS.adminRepo.GetMentorById(ctx, after.MentorId); err == nil {
+		mentorName = mentor.Name
+	}
+
+	content := fmt.Sprintf("Your session with %s on %s is cancelled", mentorName, sessionTime(*after))
+	if reason != "" {
+		content += ": " + reason
+	}
+	if err := aS.notifyStudent(ctx, after.StudentId, "Mentor session cancelled", content); err != nil {
+		aS.l.Println(err)
+	}
+
+	return *after, nil
 }
 
-func (aS AdminService) GetTaskSubmissionsForUser(ctx context.Context, userId primitive.ObjectID) ([]models.TaskSubmissionsAdminResponse, error) {
+func (aS AdminService) GetSessions(ctx context.Context, filter models.SessionFilter) (models.PageResponse, error) {
+	filter.Normalize()
 
-	return aS.adminRepo.GetTaskSubmissionsForUser(ctx, userId)
+	sessions, total, err := aS.adminRepo.GetSessions(ctx, filter)
+	if err != nil {
+		return models.PageResponse{}, err
+	}
 
+	return models.PageResponse{
+		Data:  sessions,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
 }
 
-func (aS AdminService) CreateMentor(ctx context.Context, mentor models.MentorDTO) error {
+// sessionTime formats the start of a session in the time zone it was booked in
+func sessionTime(session models.MentorSession) string {
+	start := session.StartAt.Time()
+	if loc, err := time.LoadLocation(session.TimeZone); err == nil {
+		start = start.In(loc)
+	}
 
-	m := mentor.ToMentor()
-	m.ID = primitive.NewObjectIDFromTimestamp(time.Now())
-	m.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
-	m.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	return start.Format("Mon 2 Jan 2006 15:04 MST")
+}
+
+// validateCohort checks the domain and college of an assignment request exist
+func (aS AdminService) validateCohort(ctx context.Context, selection models.MentorAssignmentDTO) error {
+	if selection.Domain != "" {
+		if _, err := aS.adminRepo.GetDomain(ctx, selection.Domain); err != nil {
+			return fmt.Errorf("%w: unknown domain %q", models.ErrInvalidCohort, selection.Domain)
+		}
+	}
+	if selection.College != "" {
+		if _, err := aS.adminRepo.GetCollege(ctx, selection.College); err != nil {
+			return fmt.Errorf("%w: unknown college %q", models.ErrInvalidCohort, selection.College)
+		}
+	}
 
-	return aS.adminRepo.CreateMentor(ctx, m)
+	return nil
 }
 
-func (aS AdminService) UpdateMentor(ctx context.Context, mentor models.MentorDTO) error {
+// AssignMentor assigns the selected students to the mentor, students already assigned are
+// left as they are. Seats for the new students are reserved on the mentor first, in a single
+// update that checks the capacity, and only the students the seats were reserved for are
+// assigned, so concurrent assignments can not overbook the mentor. Nothing is assigned when
+// the new students do not fit.
+func (aS AdminService) AssignMentor(ctx context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (models.MentorAssignmentReport, error) {
+	report := models.MentorAssignmentReport{MentorId: mentorId}
 
-	m := mentor.ToMentor()
-	m.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	mentor, err := aS.adminRepo.GetMentorById(ctx, mentorId)
+	if err != nil {
+		return report, err
+	}
+	report.Capacity = mentor.Capacity
+
+	if mentor.ArchivedAt != 0 {
+		return report, models.ErrMentorArchived
+	}
+
+	if err = aS.validateCohort(ctx, selection); err != nil {
+		return report, err
+	}
 
-	return aS.adminRepo.UpdateMentor(ctx, m)
+	studentIds, err := aS.adminRepo.GetUnassignedStudentIds(ctx, mentorId, selection)
+	if err != nil {
+		return report, err
+	}
+	requested := int64(len(studentIds))
+
+	reserved, err := aS.adminRepo.ReserveMentorSeats(ctx, mentorId, requested)
+	if err != nil {
+		return report, err
+	}
+	if !reserved {
+		current, err := aS.adminRepo.GetMentorById(ctx, mentorId)
+		if err != nil {
+			return report, err
+		}
+		return report, models.MentorCapacityError{Capacity: current.Capacity, Assigned: current.Assigned, Requested: requested}
+	}
+
+	report.Changed, err = aS.adminRepo.AssignMentor(ctx, mentorId, studentIds)
+	if err != nil {
+		if err := aS.adminRepo.AdjustMentorAssigned(ctx, mentorId, -requested); err != nil {
+			aS.l.Println("Error releasing", requested, "seats of mentor", mentorId.Hex(), err)
+		}
+		return report, err
+	}
+
+	// release the seats of students assigned by someone else or deactivated meanwhile
+	if err := aS.adminRepo.AdjustMentorAssigned(ctx, mentorId, report.Changed-requested); err != nil {
+		return report, err
+	}
+
+	report.Assigned, err = aS.adminRepo.CountMentorStudents(ctx, mentorId)
+	if err != nil {
+		return report, err
+	}
+
+	aS.audit(ctx, "assign_students", "mentor", mentorId.Hex(), nil, bson.M{"selection": selection, "report": report})
+
+	return report, nil
 }
 
-func (aS AdminService) GetMentors(ctx context.Context) ([]models.MentorResponse, error) {
+func (aS AdminService) UnassignMentor(ctx context.Context, mentorId primitive.ObjectID, selection models.MentorAssignmentDTO) (models.MentorAssignmentReport, error) {
+	report := models.MentorAssignmentReport{MentorId: mentorId}
 
-	mentors, err := aS.adminRepo.GetMentors(ctx)
+	mentor, err := aS.adminRepo.GetMentorById(ctx, mentorId)
 	if err != nil {
-		return nil, err
+		return report, err
 	}
+	report.Capacity = mentor.Capacity
 
-	mentorResponses := []models.MentorResponse{}
+	if err = aS.validateCohort(ctx, selection); err != nil {
+		return report, err
+	}
 
-	for _, dto := range mentors {
-		mentorResponses = append(mentorResponses, *dto.ToResponse())
+	changed, released, err := aS.adminRepo.UnassignMentor(ctx, mentorId, selection)
+	if releaseErr := aS.adminRepo.AdjustMentorAssigned(ctx, mentorId, -released); releaseErr != nil && err == nil {
+		err = releaseErr
+	}
+	report.Changed = changed
+	if err != nil {
+		return report, err
 	}
 
-	return mentorResponses, nil
+	report.Assigned, err = aS.adminRepo.CountMentorStudents(ctx, mentorId)
+	if err != nil {
+		return report, err
+	}
+
+	aS.audit(ctx, "unassign_students", "mentor", mentorId.Hex(), nil, bson.M{"selection": selection, "report": report})
+
+	return report, nil
+}
+
+func (aS AdminService) GetMentorStudents(ctx context.Context, mentorId primitive.ObjectID) (models.MentorStudentsResponse, error) {
+	mentor, err := aS.adminRepo.GetMentorById(ctx, mentorId)
+	if err != nil {
+		return models.MentorStudentsResponse{}, err
+	}
+
+	students, err := aS.adminRepo.GetMentorStudents(ctx, mentorId)
+	if err != nil {
+		return models.MentorStudentsResponse{}, err
+	}
+
+	return models.MentorStudentsResponse{
+		MentorId: mentorId,
+		Capacity: mentor.Capacity,
+		Total:    len(students),
+		Students: students.ToStudentResponse(),
+	}, nil
 }
+
 func (aS AdminService) CreateDomain(ctx context.Context, domainString string) error {
 
 	domain := models.StaticModel{
@@ -204,7 +2050,16 @@ func (aS AdminService) CreateDomain(ctx context.Context, domainString string) er
 		CreatedOn: primitive.NewDateTimeFromTime(time.Now()),
 	}
 
-	return aS.adminRepo.CreateDomain(ctx, domain)
+	before, _ := aS.adminRepo.GetDomain(ctx, domainString)
+
+	if err := aS.adminRepo.CreateDomain(ctx, domain); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "upsert", "domains", domainString, before, domain)
+	aS.invalidateStaticData(ctx)
+
+	return nil
 }
 
 func (aS AdminService) CreateCollege(ctx context.Context, college string) error {
@@ -213,7 +2068,16 @@ func (aS AdminService) CreateCollege(ctx context.Context, college string) error
 		CreatedOn: primitive.NewDateTimeFromTime(time.Now()),
 	}
 
-	return aS.adminRepo.CreateCollege(ctx, c)
+	before, _ := aS.adminRepo.GetCollege(ctx, college)
+
+	if err := aS.adminRepo.CreateCollege(ctx, c); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "upsert", "colleges", college, before, c)
+	aS.invalidateStaticData(ctx)
+
+	return nil
 }
 
 func (aS AdminService) CreateCourse(ctx context.Context, course string) error {
@@ -222,7 +2086,87 @@ func (aS AdminService) CreateCourse(ctx context.Context, course string) error {
 		CreatedOn: primitive.NewDateTimeFromTime(time.Now()),
 	}
 
-	return aS.adminRepo.CreateCourse(ctx, c)
+	before, _ := aS.adminRepo.GetCourse(ctx, course)
+
+	if err := aS.adminRepo.CreateCourse(ctx, c); err != nil {
+		return err
+	}
+
+	aS.audit(ctx, "upsert", "courses", course, before, c)
+	aS.invalidateStaticData(ctx)
+
+	return nil
+}
+
+func (aS AdminService) GetStaticData(ctx context.Context, kind models.StaticDataKind) ([]models.StaticModel, error) {
+	return aS.adminRepo.GetStaticData(ctx, kind)
+}
+
+// RenameStaticData renames a domain, college or course and updates the students, tasks and
+// mentors holding the old name
+func (aS AdminService) RenameStaticData(ctx context.Context, kind models.StaticDataKind, from, to string) (models.StaticRenameReport, error) {
+	report := models.StaticRenameReport{Kind: kind, From: from, To: to, Updated: map[string]int64{}}
+
+	before, err := aS.adminRepo.FindStaticData(ctx, kind, from)
+	if err != nil {
+		return report, err
+	}
+
+	if from == to {
+		return report, nil
+	}
+
+	if _, err := aS.adminRepo.FindStaticData(ctx, kind, to); err == nil {
+		return report, models.ErrStaticDataExists
+	} else if err != models.ErrNoValidRecordFound {
+		return report, err
+	}
+
+	if err := aS.adminRepo.RenameStaticData(ctx, kind, from, to); err != nil {
+		return report, err
+	}
+	aS.invalidateStaticData(ctx)
+
+	report.Updated, err = aS.adminRepo.PropagateStaticRename(ctx, kind, from, to)
+	if err != nil {
+		return report, err
+	}
+
+	aS.audit(ctx, "rename", kind.String(), from, before, report)
+
+	return report, nil
+}
+
+// DeleteStaticData deletes a domain, college or course that nothing references anymore
+func (aS AdminService) DeleteStaticData(ctx context.Context, kind models.StaticDataKind, name string) error {
+	before, err := aS.adminRepo.FindStaticData(ctx, kind, name)
+	if err != nil {
+		return err
+	}
+
+	references, err := aS.adminRepo.CountStaticReferences(ctx, kind, name)
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		return models.StaticDataInUseError{References: references}
+	}
+
+	if err := aS.adminRepo.DeleteStaticData(ctx, kind, name); err != nil {
+		return err
+	}
+	aS.invalidateStaticData(ctx)
+
+	aS.audit(ctx, "delete", kind.String(), name, before, nil)
+
+	return nil
+}
+
+// invalidateStaticData drops the cached static data so the next GetData reads it again
+func (aS AdminService) invalidateStaticData(ctx context.Context) {
+	if err := aS.rClient.Del(ctx, "static_data").Err(); err != nil {
+		aS.l.Println(err)
+	}
 }
 
 func (aS AdminService) GetData(ctx context.Context) (models.Data, error) {
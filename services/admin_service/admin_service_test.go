@@ -0,0 +1,70 @@
+package admin_service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asishshaji/admin-api/models"
+)
+
+func TestLoginLockDuration(t *testing.T) {
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{5, 15 * time.Minute},
+		{6, 30 * time.Minute},
+		{7, time.Hour},
+		{8, 2 * time.Hour},
+		{11, 16 * time.Hour},
+		{12, 24 * time.Hour},
+		{13, 24 * time.Hour},
+		{1000, 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := loginLockDuration(tt.failures); got != tt.want {
+			t.Errorf("loginLockDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSubmissionTransition(t *testing.T) {
+	statuses := []models.Status{
+		models.INACTIVE, models.ACTIVE, models.NEEDS_REVISION, models.REJECTED, models.COMPLETED,
+	}
+
+	allowed := map[models.Status]map[models.Status]bool{
+		models.ACTIVE:         {models.COMPLETED: true, models.REJECTED: true, models.NEEDS_REVISION: true},
+		models.NEEDS_REVISION: {models.COMPLETED: true, models.REJECTED: true},
+		models.REJECTED:       {models.NEEDS_REVISION: true},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			err := checkSubmissionTransition(from, to)
+
+			if allowed[from][to] {
+				if err != nil {
+					t.Errorf("%s -> %s rejected: %v", from, to, err)
+				}
+				continue
+			}
+
+			var transitionErr models.InvalidTransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Errorf("%s -> %s returned %v, want InvalidTransitionError", from, to, err)
+				continue
+			}
+			if transitionErr.From != from || transitionErr.To != to || len(transitionErr.Allowed) != len(allowed[from]) {
+				t.Errorf("%s -> %s returned %+v", from, to, transitionErr)
+			}
+		}
+	}
+}
@@ -7,4 +7,5 @@ import (
 
 type IFileService interface {
 	UploadFile(ctx context.Context, file multipart.File) (string, error)
+	UploadVideo(ctx context.Context, file multipart.File) (videoUrl, thumbUrl string, err error)
 }
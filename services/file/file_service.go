@@ -2,9 +2,12 @@ package file_service
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"mime/multipart"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/cloudinary/cloudinary-go"
 	"github.com/cloudinary/cloudinary-go/api/uploader"
@@ -45,3 +48,22 @@ func (iS FileService) UploadFile(ctx context.Context, file multipart.File) (stri
 
 	return res.SecureURL, nil
 }
+
+// UploadVideo uploads a video, the thumbnail is a frame cloudinary derives from the video url
+// when its extension is swapped for an image one
+func (iS FileService) UploadVideo(ctx context.Context, file multipart.File) (string, string, error) {
+	res, err := iS.client.Upload.Upload(ctx, file, uploader.UploadParams{ResourceType: "video"})
+	if err != nil {
+		iS.l.Println(err)
+		return "", "", err
+	}
+
+	if res.Error.Message != "" {
+		iS.l.Println(res.Error.Message)
+		return "", "", fmt.Errorf("upload failed: %s", res.Error.Message)
+	}
+
+	thumbUrl := strings.TrimSuffix(res.SecureURL, path.Ext(res.SecureURL)) + ".jpg"
+
+	return res.SecureURL, thumbUrl, nil
+}
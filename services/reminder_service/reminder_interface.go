@@ -0,0 +1,9 @@
+package reminder_service
+
+import "context"
+
+type IReminderService interface {
+	// Run sends reminders on every tick until ctx is done
+	Run(ctx context.Context)
+	SendDueReminders(ctx context.Context) error
+}
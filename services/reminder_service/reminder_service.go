@@ -0,0 +1,140 @@
+package reminder_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/asishshaji/admin-api/models"
+	admin_repository "github.com/asishshaji/admin-api/repositories"
+	"github.com/asishshaji/admin-api/services/notification_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	reminderInterval   = time.Hour
+	defaultDaysBefore  = 2
+	reminderDaysEnvKey = "REMINDER_DAYS_BEFORE"
+)
+
+// ReminderService notifies students who have not submitted a task that is due within
+// REMINDER_DAYS_BEFORE days, once per due date of the task
+type ReminderService struct {
+	l                   *log.Logger
+	adminRepo           admin_repository.IAdminRepository
+	notificationService notification_service.INotificationService
+	remindBefore        time.Duration
+}
+
+func NewReminderService(l *log.Logger, adminRepo admin_repository.IAdminRepository, notification notification_service.INotificationService) IReminderService {
+	days := defaultDaysBefore
+	if v := os.Getenv(reminderDaysEnvKey); v != "" {
+		if d, err := strconv.Atoi(v); err == nil && d > 0 {
+			days = d
+		} else {
+			l.Println("Invalid", reminderDaysEnvKey, v)
+		}
+	}
+
+	return ReminderService{
+		l:                   l,
+		adminRepo:           adminRepo,
+		notificationService: notification,
+		remindBefore:        time.Hour * 24 * time.Duration(days),
+	}
+}
+
+func (rS ReminderService) Run(ctx context.Context) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := rS.SendDueReminders(ctx); err != nil {
+			rS.l.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (rS ReminderService) SendDueReminders(ctx context.Context) error {
+	now := time.Now()
+
+	tasks, err := rS.adminRepo.GetTasksDueForReminder(ctx, now, now.Add(rS.remindBefore))
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if err := rS.sendTaskReminders(ctx, task); err != nil {
+			rS.l.Println("Error sending reminders for task", task.Id.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+func (rS ReminderService) sendTaskReminders(ctx context.Context, task models.Task) error {
+	// claiming first keeps two instances from reminding the same students
+	claimed, err := rS.adminRepo.ClaimTaskReminder(ctx, task)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	students, err := rS.adminRepo.GetPendingStudentsForTask(ctx, task)
+	if err != nil {
+		// release the claim so the next run retries the task
+		if releaseErr := rS.adminRepo.ReleaseTaskReminder(ctx, task); releaseErr != nil {
+			rS.l.Println("Error releasing reminder claim for task", task.Id.Hex(), releaseErr)
+		}
+		return err
+	}
+
+	rS.l.Println("Sending", len(students), "reminders for task", task.Id.Hex())
+
+	for _, student := range students {
+		rS.remind(ctx, student.ID, task)
+	}
+
+	return nil
+}
+
+func (rS ReminderService) remind(ctx context.Context, uid primitive.ObjectID, task models.Task) {
+	title := "Reminder: " + task.Title
+	content := fmt.Sprintf("%s is due on %s, submit it before the deadline", task.Title, task.DueAt.Time().Format("02 Jan 2006 15:04"))
+
+	tK, err := rS.adminRepo.GetToken(ctx, uid)
+	if err != nil {
+		rS.l.Println(err)
+	}
+
+	err = rS.notificationService.SendNotification(ctx, models.NotificationMessage{
+		UserToken: tK.Token,
+		Heading:   map[string]string{"en": title},
+		Contents:  map[string]string{"en": content},
+	})
+	if err != nil {
+		rS.l.Println(err)
+	}
+
+	err = rS.adminRepo.CreateNotification(ctx, models.NotificationEntity{
+		UserId:    uid,
+		Content:   content,
+		Title:     title,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		Image:     "",
+	})
+	if err != nil {
+		rS.l.Println(err)
+	}
+}
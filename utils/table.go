@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/asishshaji/admin-api/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// ReadTable reads every row of a csv file or of the first sheet of an xlsx workbook
+func ReadTable(r io.Reader, format models.FileFormat) ([][]string, error) {
+	switch format {
+	case models.CSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		table, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+
+		return unescapeRows(table), nil
+	case models.XLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("workbook has no sheets")
+		}
+
+		rows, err := f.Rows(sheets[0])
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		table := [][]string{}
+		for rows.Next() {
+			columns, err := rows.Columns()
+			if err != nil {
+				return nil, err
+			}
+
+			for i, v := range columns {
+				if v == "" {
+					continue
+				}
+				if columns[i], err = xlsxStringCell(f, sheets[0], i+1, len(table)+1, v); err != nil {
+					return nil, err
+				}
+			}
+			table = append(table, columns)
+		}
+
+		return table, nil
+	}
+
+	return nil, fmt.Errorf("unsupported file format %q", format)
+}
+
+// xlsxStringCell returns string cells as they are stored and anything else as formatted. Excelize
+// formats strings that parse as a number too, which drops the + of phone numbers.
+func xlsxStringCell(f *excelize.File, sheet string, col, row int, formatted string) (string, error) {
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return "", err
+	}
+
+	cellType, err := f.GetCellType(sheet, cell)
+	if err != nil || cellType != excelize.CellTypeString {
+		return formatted, err
+	}
+
+	return f.GetCellValue(sheet, cell, excelize.Options{RawCellValue: true})
+}
+
+// formulaPrefixes start a formula when a spreadsheet opens the cell
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCell prefixes values spreadsheets would run as formulas with a quote, exports carry
+// values students typed in. Only csv needs it, xlsx cells are written as strings which are
+// never run.
+func escapeCell(v string) string {
+	if v != "" && strings.ContainsRune(formulaPrefixes, rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// unescapeCell undoes escapeCell so exported csv files can be imported again
+func unescapeCell(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
+
+func unescapeRows(table [][]string) [][]string {
+	for _, row := range table {
+		for i, v := range row {
+			row[i] = unescapeCell(v)
+		}
+	}
+	return table
+}
+
+func escapeRow(row []string) []string {
+	escaped := make([]string, len(row))
+	for i, v := range row {
+		escaped[i] = escapeCell(v)
+	}
+	return escaped
+}
+
+// TableWriter writes rows one at a time, Close flushes whatever is still buffered
+type TableWriter interface {
+	WriteRow(row []string) error
+	Close() error
+}
+
+// NewTableWriter returns a writer producing the given format on w. Csv rows are written through
+// as they come, xlsx rows are kept by the excelize stream writer, which spills to a temporary
+// file once it grows, and the workbook is written to w on Close.
+func NewTableWriter(w io.Writer, format models.FileFormat) (TableWriter, error) {
+	switch format {
+	case models.CSV:
+		return &csvTableWriter{w: csv.NewWriter(w)}, nil
+	case models.XLSX:
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter(f.GetSheetName(0))
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxTableWriter{out: w, f: f, sw: sw}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported file format %q", format)
+}
+
+// csvFlushRows is how many rows are buffered before they are flushed to the client
+const csvFlushRows = 500
+
+type csvTableWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (t *csvTableWriter) WriteRow(row []string) error {
+	if err := t.w.Write(escapeRow(row)); err != nil {
+		return err
+	}
+
+	t.rows++
+	if t.rows%csvFlushRows == 0 {
+		t.w.Flush()
+		return t.w.Error()
+	}
+
+	return nil
+}
+
+func (t *csvTableWriter) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+type xlsxTableWriter struct {
+	out  io.Writer
+	f    *excelize.File
+	sw   *excelize.StreamWriter
+	rows int
+}
+
+func (t *xlsxTableWriter) WriteRow(row []string) error {
+	t.rows++
+
+	cell, err := excelize.CoordinatesToCellName(1, t.rows)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		values[i] = v
+	}
+
+	return t.sw.SetRow(cell, values)
+}
+
+func (t *xlsxTableWriter) Close() error {
+	if err := t.sw.Flush(); err != nil {
+		return err
+	}
+
+	return t.f.Write(t.out)
+}
+
+// FileFormatFromName returns the format for a file name by its extension
+func FileFormatFromName(name string) models.FileFormat {
+	switch {
+	case strings.HasSuffix(strings.ToLower(name), ".csv"):
+		return models.CSV
+	case strings.HasSuffix(strings.ToLower(name), ".xlsx"):
+		return models.XLSX
+	}
+	return ""
+}
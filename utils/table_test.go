@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/asishshaji/admin-api/models"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestEscapeCell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"Asha", "Asha"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+919876543210", "'+919876543210"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"a=b", "a=b"},
+		{"'quoted", "'quoted"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeCell(tt.in); got != tt.want {
+			t.Errorf("escapeCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got := unescapeCell(escapeCell(tt.in)); got != tt.in {
+			t.Errorf("unescapeCell(escapeCell(%q)) = %q", tt.in, got)
+		}
+	}
+}
+
+func TestTableRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"email", "first_name", "phone_number"},
+		{"a@example.com", "=1+1", "+919876543210"},
+		{"b@example.com", "Ravi", "9876543210"},
+	}
+
+	for _, format := range []models.FileFormat{models.CSV, models.XLSX} {
+		t.Run(format.String(), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			w, err := NewTableWriter(buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := w.WriteRow(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if format == models.CSV && bytes.Contains(buf.Bytes(), []byte(",=1+1,")) {
+				t.Errorf("formula written unescaped: %s", buf.String())
+			}
+			if format == models.XLSX {
+				f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				// string cells are never run, so they are stored as they are
+				if v, _ := f.GetCellValue(f.GetSheetName(0), "C2", excelize.Options{RawCellValue: true}); v != "+919876543210" {
+					t.Errorf("xlsx cell C2 = %q, want %q", v, "+919876543210")
+				}
+			}
+
+			got, err := ReadTable(buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, rows) {
+				t.Errorf("ReadTable = %q, want %q", got, rows)
+			}
+		})
+	}
+}
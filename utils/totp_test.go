@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 key of RFC 6238 appendix B, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPRFC6238Vectors(t *testing.T) {
+	// the RFC lists 8 digit codes, these are their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(%q) at %d rejected a valid code", tt.code, tt.unix)
+			continue
+		}
+		if want := tt.unix / totpPeriod; step != want {
+			t.Errorf("ValidateTOTP(%q) at %d matched step %d, want %d", tt.code, tt.unix, step, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkewWindow(t *testing.T) {
+	// 287082 is the code of step 1 (T=59)
+	tests := []struct {
+		name string
+		unix int64
+		ok   bool
+	}{
+		{"two steps early", 0 - 2*totpPeriod, false},
+		{"one step early", 0, true},
+		{"current step", 59, true},
+		{"one step late", 60, true},
+		{"two steps late", 90, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, "287082", time.Unix(tt.unix, 0))
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != 1 {
+				t.Errorf("ValidateTOTP matched step %d, want the code's own step 1", step)
+			}
+		})
+	}
+}
+
+// the matched step is what callers store to reject a replayed code, so a code must map to the
+// same step wherever in the skew window it is used
+func TestValidateTOTPReplayStep(t *testing.T) {
+	first, ok := ValidateTOTP(rfc6238Secret, "287082", time.Unix(45, 0))
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+
+	replayed, ok := ValidateTOTP(rfc6238Secret, "287082", time.Unix(75, 0))
+	if !ok {
+		t.Fatal("code inside the skew window rejected")
+	}
+
+	if replayed > first {
+		t.Errorf("replayed code matched step %d after step %d was used, it would be accepted again", replayed, first)
+	}
+}
+
+func TestValidateTOTPMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"short code", rfc6238Secret, "28708"},
+		{"long code", rfc6238Secret, "2870820"},
+		{"empty code", rfc6238Secret, ""},
+		{"invalid secret", "not base32!", "287082"},
+		{"wrong code", rfc6238Secret, "287083"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+				t.Errorf("ValidateTOTP(%q, %q) accepted", tt.secret, tt.code)
+			}
+		})
+	}
+}
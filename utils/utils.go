@@ -2,9 +2,15 @@ package utils
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/asishshaji/admin-api/models"
@@ -12,6 +18,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
@@ -74,11 +81,35 @@ func (env *EnvironmentConfig) ConnectToDB() *mongo.Database {
 
 }
 
+const PasswordHashCost = 12
+
 func Hashpassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 2)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), PasswordHashCost)
 	return string(bytes), err
 }
 
+// NeedsRehash reports whether the hash was generated with a lower cost than PasswordHashCost
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < PasswordHashCost
+}
+
+// GenerateToken returns a random hex token and its sha256 hash, only the hash should be stored
+func GenerateToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func CheckpasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
@@ -94,19 +125,142 @@ func ToDoc(v interface{}) (doc *bson.D, err error) {
 	return
 }
 
+// ToMap converts a model into a bson.M using its bson tags, nil stays nil
+func ToMap(v interface{}) (bson.M, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := bson.M{}
+	err = bson.Unmarshal(data, &m)
+	return m, err
+}
+
+// Diff returns the top level fields that differ between the two documents
+func Diff(before, after bson.M) map[string]models.AuditChange {
+	diff := map[string]models.AuditChange{}
+
+	for k, v := range before {
+		if a, ok := after[k]; !ok || !reflect.DeepEqual(v, a) {
+			diff[k] = models.AuditChange{Before: v, After: after[k]}
+		}
+	}
+
+	for k, v := range after {
+		if _, ok := before[k]; !ok {
+			diff[k] = models.AuditChange{Before: nil, After: v}
+		}
+	}
+
+	return diff
+}
+
+type adminContextKey struct{}
+
+func ContextWithAdminID(ctx context.Context, adminId primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, adminContextKey{}, adminId)
+}
+
+// AdminIDFromContext returns the id of the authenticated admin, or NilObjectID outside of the /admin routes
+func AdminIDFromContext(ctx context.Context) primitive.ObjectID {
+	adminId, _ := ctx.Value(adminContextKey{}).(primitive.ObjectID)
+	return adminId
+}
+
 func AdminAuthenticationMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		admin := c.Get("user").(*jwt.Token)
 		claims := admin.Claims.(*models.AdminJWTClaims)
-		if !claims.IsAdmin {
+		if !claims.IsAdmin || claims.TokenType != models.ACCESS_TOKEN {
 			return echo.ErrForbidden
 		}
 
 		c.Set("admin_id", claims.AdminId)
+		c.Set("admin_role", claims.Role)
+		c.SetRequest(c.Request().WithContext(ContextWithAdminID(c.Request().Context(), claims.AdminId)))
 		return next(c)
 	}
 }
 
+// RevocationChecker reports whether the token has been revoked, by its id (jti) or because
+// it was issued before the admin's sessions were invalidated
+type RevocationChecker func(ctx context.Context, claims *models.AdminJWTClaims) (bool, error)
+
+// TokenRevocationMiddleware rejects tokens that were revoked before they expired,
+// it has to run after the JWT middleware
+func TokenRevocationMiddleware(isRevoked RevocationChecker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			admin := c.Get("user").(*jwt.Token)
+			claims := admin.Claims.(*models.AdminJWTClaims)
+			if claims.Id == "" {
+				return echo.ErrUnauthorized
+			}
+
+			revoked, err := isRevoked(c.Request().Context(), claims)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "unable to verify token")
+			}
+			if revoked {
+				return echo.ErrUnauthorized
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func SignAdminToken(claims *models.AdminJWTClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+func ParseAdminToken(tokenString string) (*models.AdminJWTClaims, error) {
+	claims := &models.AdminJWTClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, models.ErrInvalidToken
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, models.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// RequirePermission rejects admins whose token does not carry the given permission
+func RequirePermission(permission models.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			admin := c.Get("user").(*jwt.Token)
+			claims := admin.Claims.(*models.AdminJWTClaims)
+			if !claims.HasPermission(permission) {
+				return c.JSON(http.StatusForbidden, models.ErrorResponse{
+					Code:    "permission_denied",
+					Message: "admin does not have the required permission",
+					Details: map[string]interface{}{
+						"permission": permission,
+						"role":       claims.Role,
+					},
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
 func CreateIndex(db *mongo.Database, collectionName string, field string, unique bool) bool {
 
 	// 1. Lets define the keys for the index we want to create
@@ -133,3 +287,35 @@ func CreateIndex(db *mongo.Database, collectionName string, field string, unique
 	// 6. All went well, we return true
 	return true
 }
+
+// CreateTextIndex creates the text index of a collection, MongoDB allows only one per
+// collection so all searchable fields go into it with their relative weights
+func CreateTextIndex(db *mongo.Database, collectionName string, weights map[string]int32) error {
+	fields := []string{}
+	for field := range weights {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	keys := bson.D{}
+	weightDoc := bson.M{}
+
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weightDoc[field] = weights[field]
+	}
+
+	mod := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetWeights(weightDoc).SetName(collectionName + "_text"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Collection(collectionName).Indexes().CreateOne(ctx, mod); err != nil {
+		return fmt.Errorf("creating text index on %s: %w", collectionName, err)
+	}
+
+	return nil
+}